@@ -0,0 +1,37 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// GetAssetDigest returns the list of asset bundles available for the platform and app version
+func (s *Session) GetAssetDigest(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.GetAssetDigestResponse, error) {
+	response := &protos.GetAssetDigestResponse{}
+	err := s.callSingle(ctx, protos.RequestType_GET_ASSET_DIGEST, &protos.GetAssetDigestMessage{
+		Platform:   platform,
+		AppVersion: appVersion,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.GetAssetDigestResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+	return response, nil
+}
+
+// GetDownloadURLs returns the locations the asset bundles can be downloaded from
+//
+// The response has no result, assets the remote service does not know are left out of it.
+func (s *Session) GetDownloadURLs(ctx context.Context, assetIDs []string, proxyId int64) (*protos.GetDownloadUrlsResponse, error) {
+	response := &protos.GetDownloadUrlsResponse{}
+	err := s.callSingle(ctx, protos.RequestType_GET_DOWNLOAD_URLS, &protos.GetDownloadUrlsMessage{
+		AssetId: assetIDs,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestGetAssetDigest(t *testing.T) {
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.GetAssetDigestResponse{
+			Result: protos.GetAssetDigestResponse_SUCCESS,
+			Digest: []*protos.AssetDigestEntry{{AssetId: "pm0001/1", BundleName: "pm0001", Checksum: 1}},
+		}},
+		[]proto.Message{&protos.GetAssetDigestResponse{Result: protos.GetAssetDigestResponse_PAGE}},
+	)
+	defer server.Close()
+	session := server.session()
+	ctx := context.Background()
+
+	digest, err := session.GetAssetDigest(ctx, protos.Platform_IOS, 4500, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest.Digest) != 1 || digest.Digest[0].AssetId != "pm0001/1" {
+		t.Errorf("unexpected digest %v", digest.Digest)
+	}
+	message := &protos.GetAssetDigestMessage{}
+	server.lastMessage(message)
+	if message.Platform != protos.Platform_IOS || message.AppVersion != 4500 {
+		t.Errorf("unexpected digest message %v", message)
+	}
+
+	digest, err = session.GetAssetDigest(ctx, protos.Platform_IOS, 4500, -1)
+	if _, ok := err.(*ErrResult); !ok || digest != nil {
+		t.Errorf("expected a result error and no response, got %v %v", digest, err)
+	}
+}
+
+func TestGetDownloadURLs(t *testing.T) {
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.GetDownloadUrlsResponse{
+			DownloadUrls: []*protos.DownloadUrlEntry{{AssetId: "pm0001/1", Url: "https://cdn/pm0001", Size: 9}},
+		}},
+	)
+	defer server.Close()
+
+	urls, err := server.session().GetDownloadURLs(context.Background(), []string{"pm0001/1"}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls.DownloadUrls) != 1 || urls.DownloadUrls[0].Url != "https://cdn/pm0001" {
+		t.Errorf("unexpected download urls %v", urls.DownloadUrls)
+	}
+	message := &protos.GetDownloadUrlsMessage{}
+	server.lastMessage(message)
+	if len(message.AssetId) != 1 || message.AssetId[0] != "pm0001/1" {
+		t.Errorf("unexpected download urls message %v", message)
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+// OverflowPolicy decides what a channel feed does with entries pushed while its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the sink to make room, stalling the session
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being pushed
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered entry to make room for the new one
+	OverflowDropOldest
+	// OverflowSample keeps one in every SampleEvery pushed entries in place of the oldest and drops the others
+	OverflowSample
+)
+
+// ChanFeedStats are the counters of a channel feed
+type ChanFeedStats struct {
+	Pushed    uint64
+	Delivered uint64
+	Dropped   uint64
+}
+
+// ChanFeed is a feed buffering entries in a channel and passing them on to a sink in the background,
+// so that a slow sink does not hold up the session
+type ChanFeed struct {
+	// SampleEvery is how many overflowing entries it takes for one to be kept with OverflowSample
+	SampleEvery uint64
+
+	sink   Feed
+	policy OverflowPolicy
+	buffer chan interface{}
+
+	// quit wakes up blocked pushes, stopped tells the sink loop to drain and done is closed once it has
+	quit    chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	mutex  sync.RWMutex
+	closed bool
+
+	pushed     uint64
+	delivered  uint64
+	dropped    uint64
+	overflowed uint64
+}
+
+// NewChanFeed constructs a feed buffering up to size entries for the sink and starts passing them on
+func NewChanFeed(sink Feed, size int, policy OverflowPolicy) *ChanFeed {
+	f := &ChanFeed{
+		SampleEvery: 10,
+		sink:        sink,
+		policy:      policy,
+		buffer:      make(chan interface{}, size),
+		quit:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+func (f *ChanFeed) run() {
+	defer close(f.done)
+	for {
+		select {
+		case entry := <-f.buffer:
+			f.deliver(entry)
+		case <-f.stopped:
+			for {
+				select {
+				case entry := <-f.buffer:
+					f.deliver(entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (f *ChanFeed) deliver(entry interface{}) {
+	deliver(f.sink, entry)
+	atomic.AddUint64(&f.delivered, 1)
+}
+
+// Push buffers a message for the sink
+func (f *ChanFeed) Push(entry interface{}) {
+	f.enqueue(entry)
+}
+
+// PushEntry buffers an entry for the sink
+func (f *ChanFeed) PushEntry(entry *FeedEntry) {
+	f.enqueue(entry)
+}
+
+func (f *ChanFeed) enqueue(entry interface{}) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	atomic.AddUint64(&f.pushed, 1)
+	if f.closed {
+		atomic.AddUint64(&f.dropped, 1)
+		return
+	}
+
+	if f.policy == OverflowBlock {
+		select {
+		case f.buffer <- entry:
+		case <-f.quit:
+			atomic.AddUint64(&f.dropped, 1)
+		}
+		return
+	}
+
+	select {
+	case f.buffer <- entry:
+		return
+	default:
+	}
+
+	switch f.policy {
+	case OverflowDropOldest:
+		f.replaceOldest(entry)
+	case OverflowSample:
+		if f.SampleEvery > 0 && atomic.AddUint64(&f.overflowed, 1)%f.SampleEvery == 0 {
+			f.replaceOldest(entry)
+		} else {
+			atomic.AddUint64(&f.dropped, 1)
+		}
+	default:
+		atomic.AddUint64(&f.dropped, 1)
+	}
+}
+
+// replaceOldest drops the oldest buffered entry to make room, or drops the entry itself
+// when other pushes took the room first
+func (f *ChanFeed) replaceOldest(entry interface{}) {
+	select {
+	case <-f.buffer:
+		atomic.AddUint64(&f.dropped, 1)
+	default:
+	}
+
+	select {
+	case f.buffer <- entry:
+	default:
+		atomic.AddUint64(&f.dropped, 1)
+	}
+}
+
+// Stats returns the number of entries pushed, passed on to the sink and dropped so far
+func (f *ChanFeed) Stats() ChanFeedStats {
+	return ChanFeedStats{
+		Pushed:    atomic.LoadUint64(&f.pushed),
+		Delivered: atomic.LoadUint64(&f.delivered),
+		Dropped:   atomic.LoadUint64(&f.dropped),
+	}
+}
+
+// Close stops accepting entries and waits until the buffered entries are passed on to the sink,
+// returning early when the context is done
+//
+// Pushes blocked on a full buffer when the feed is closed drop their entries.
+func (f *ChanFeed) Close(ctx context.Context) error {
+	f.once.Do(func() {
+		close(f.quit)
+		f.mutex.Lock()
+		f.closed = true
+		f.mutex.Unlock()
+		close(f.stopped)
+	})
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// gatedFeed records messages once the gate is opened
+type gatedFeed struct {
+	gate     chan struct{}
+	mutex    sync.Mutex
+	messages []interface{}
+}
+
+func (f *gatedFeed) Push(entry interface{}) {
+	<-f.gate
+	f.mutex.Lock()
+	f.messages = append(f.messages, entry)
+	f.mutex.Unlock()
+}
+
+func pushPlayers(feed Feed, n int) []*protos.GetPlayerResponse {
+	players := make([]*protos.GetPlayerResponse, n)
+	for i := range players {
+		players[i] = &protos.GetPlayerResponse{}
+		feed.Push(players[i])
+	}
+	return players
+}
+
+// fillChanFeed pushes a first entry that the sink holds on to, then fills the buffer
+func fillChanFeed(t *testing.T, policy OverflowPolicy) (*ChanFeed, *gatedFeed) {
+	sink := &gatedFeed{gate: make(chan struct{})}
+	feed := NewChanFeed(sink, 2, policy)
+	feed.Push(&protos.GetPlayerResponse{})
+	for feed.Stats().Pushed != 1 || len(feed.buffer) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return feed, sink
+}
+
+func closeChanFeed(t *testing.T, feed *ChanFeed, sink *gatedFeed) {
+	close(sink.gate)
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChanFeedDropNewest(t *testing.T) {
+	feed, sink := fillChanFeed(t, OverflowDropNewest)
+	players := pushPlayers(feed, 4)
+	closeChanFeed(t, feed, sink)
+
+	stats := feed.Stats()
+	if stats.Pushed != 5 || stats.Delivered != 3 || stats.Dropped != 2 {
+		t.Errorf("expected 5 pushed, 3 delivered and 2 dropped, got %+v", stats)
+	}
+	if sink.messages[1] != players[0] || sink.messages[2] != players[1] {
+		t.Errorf("expected the first entries to be kept")
+	}
+}
+
+func TestChanFeedDropOldest(t *testing.T) {
+	feed, sink := fillChanFeed(t, OverflowDropOldest)
+	players := pushPlayers(feed, 4)
+	closeChanFeed(t, feed, sink)
+
+	if stats := feed.Stats(); stats.Delivered != 3 || stats.Dropped != 2 {
+		t.Errorf("expected 3 delivered and 2 dropped, got %+v", stats)
+	}
+	if sink.messages[1] != players[2] || sink.messages[2] != players[3] {
+		t.Errorf("expected the last entries to be kept")
+	}
+}
+
+func TestChanFeedSample(t *testing.T) {
+	feed, sink := fillChanFeed(t, OverflowSample)
+	feed.SampleEvery = 3
+	players := pushPlayers(feed, 8)
+	closeChanFeed(t, feed, sink)
+
+	// 2 entries fit, of the 6 overflowing entries the 3rd and 6th are kept in place of the oldest
+	if stats := feed.Stats(); stats.Delivered != 3 || stats.Dropped != 6 {
+		t.Errorf("expected 3 delivered and 6 dropped, got %+v", stats)
+	}
+	if sink.messages[1] != players[4] || sink.messages[2] != players[7] {
+		t.Errorf("expected the sampled entries to be kept")
+	}
+}
+
+func TestChanFeedBlock(t *testing.T) {
+	feed, sink := fillChanFeed(t, OverflowBlock)
+	pushPlayers(feed, 2)
+
+	pushed := make(chan struct{})
+	go func() {
+		feed.Push(&protos.GetPlayerResponse{})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("expected the push to block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	closeChanFeed(t, feed, sink)
+	<-pushed
+	if stats := feed.Stats(); stats.Pushed != stats.Delivered+stats.Dropped {
+		t.Errorf("expected every entry to be delivered or dropped, got %+v", stats)
+	}
+}
+
+func TestChanFeedCloseTimeout(t *testing.T) {
+	feed, sink := fillChanFeed(t, OverflowDropNewest)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := feed.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the close to time out while the sink is stuck, got %v", err)
+	}
+	close(sink.gate)
+	feed.Push(&protos.GetPlayerResponse{})
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stats := feed.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected pushes after closing to be dropped, got %+v", stats)
+	}
+}
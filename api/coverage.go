@@ -0,0 +1,171 @@
+package api
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Coverage is a common interface for choosing the map cells to request around a location
+type Coverage interface {
+	CellIDs(l *Location) CellIDs
+}
+
+// DefaultCoverage covers the level 15 cell of the location and its four edge neighbours
+type DefaultCoverage struct{}
+
+// CellIDs returns the closed neighbourhood cell ids of the location
+func (c *DefaultCoverage) CellIDs(l *Location) CellIDs {
+	return l.GetCellIDs()
+}
+
+// RadiusCoverage covers all cells that are within a radius in metres of the location
+//
+// A zero level defaults to level 15 and a zero MaxCells means the amount of cells is not capped.
+type RadiusCoverage struct {
+	Radius   float64
+	Level    int
+	MaxCells int
+}
+
+// CellIDs returns the cells within the radius ordered by distance from the location
+func (c *RadiusCoverage) CellIDs(l *Location) CellIDs {
+	return l.GetCellIDsWithinRadius(c.Radius, c.Level, c.MaxCells)
+}
+
+// RingCoverage covers the cell of the location and the given amount of rings of surrounding cells
+//
+// A zero level defaults to level 15 and a zero MaxCells means the amount of cells is not capped.
+type RingCoverage struct {
+	Rings    int
+	Level    int
+	MaxCells int
+}
+
+// CellIDs returns the cells of the rings ordered by distance from the location
+func (c *RingCoverage) CellIDs(l *Location) CellIDs {
+	return l.GetCellIDsInRings(c.Rings, c.Level, c.MaxCells)
+}
+
+func coverageLevel(level int) int {
+	if level <= 0 || level > s2.MaxLevel {
+		return cellIDLevel
+	}
+	return level
+}
+
+// GetCellIDsWithinRadius returns the cells of a level that intersect the circle around the location,
+// ordered by distance from the location and capped at maxCells unless it is zero
+//
+// Cells are visited nearest first, so the search stops as soon as maxCells cells are found.
+func (l *Location) GetCellIDsWithinRadius(radius float64, level int, maxCells int) CellIDs {
+	level = coverageLevel(level)
+	center := s2.LatLngFromDegrees(l.Lat, l.Lon)
+	circle := s2.CapFromCenterAngle(s2.PointFromLatLng(center), s1.Angle(radius/earthRadiusInMeters))
+
+	origin := s2.CellIDFromLatLng(center).Parent(level)
+	visited := map[s2.CellID]bool{origin: true}
+	queue := &cellQueue{{origin, 0}}
+	cellIDs := []s2.CellID{}
+
+	for queue.Len() > 0 && (maxCells <= 0 || len(cellIDs) < maxCells) {
+		cellID := heap.Pop(queue).(queuedCell).cellID
+		if cellID != origin && !circle.IntersectsCell(s2.CellFromCellID(cellID)) {
+			continue
+		}
+		cellIDs = append(cellIDs, cellID)
+		for _, neighbour := range cellID.EdgeNeighbors() {
+			if !visited[neighbour] {
+				visited[neighbour] = true
+				heap.Push(queue, queuedCell{neighbour, center.Distance(neighbour.LatLng())})
+			}
+		}
+	}
+
+	return sortByDistance(center, origin, cellIDs, maxCells)
+}
+
+// GetCellIDsInRings returns the cell of a level containing the location and the surrounding rings of cells,
+// ordered by distance from the location and capped at maxCells unless it is zero
+func (l *Location) GetCellIDsInRings(rings int, level int, maxCells int) CellIDs {
+	level = coverageLevel(level)
+	center := s2.LatLngFromDegrees(l.Lat, l.Lon)
+
+	origin := s2.CellIDFromLatLng(center).Parent(level)
+	visited := map[s2.CellID]bool{origin: true}
+	ring := []s2.CellID{origin}
+	cellIDs := []s2.CellID{origin}
+
+	for i := 0; i < rings; i++ {
+		next := []s2.CellID{}
+		for _, cellID := range ring {
+			for _, neighbour := range cellID.AllNeighbors(level) {
+				if !visited[neighbour] {
+					visited[neighbour] = true
+					next = append(next, neighbour)
+				}
+			}
+		}
+		cellIDs = append(cellIDs, next...)
+		ring = next
+	}
+
+	return sortByDistance(center, origin, cellIDs, maxCells)
+}
+
+func sortByDistance(center s2.LatLng, origin s2.CellID, cellIDs []s2.CellID, maxCells int) CellIDs {
+	sorted := &cellsByDistance{
+		cellIDs:   cellIDs,
+		distances: make([]s1.Angle, len(cellIDs)),
+	}
+	for i, cellID := range cellIDs {
+		// The cell containing the location always comes first, the others by the distance to their center
+		if cellID != origin {
+			sorted.distances[i] = center.Distance(cellID.LatLng())
+		}
+	}
+	sort.Sort(sorted)
+
+	if maxCells > 0 && len(cellIDs) > maxCells {
+		cellIDs = cellIDs[:maxCells]
+	}
+
+	result := make(CellIDs, len(cellIDs))
+	for i, cellID := range cellIDs {
+		result[i] = uint64(cellID)
+	}
+	return result
+}
+
+type cellsByDistance struct {
+	cellIDs   []s2.CellID
+	distances []s1.Angle
+}
+
+func (a *cellsByDistance) Len() int { return len(a.cellIDs) }
+func (a *cellsByDistance) Swap(i, j int) {
+	a.cellIDs[i], a.cellIDs[j] = a.cellIDs[j], a.cellIDs[i]
+	a.distances[i], a.distances[j] = a.distances[j], a.distances[i]
+}
+func (a *cellsByDistance) Less(i, j int) bool { return a.distances[i] < a.distances[j] }
+
+type queuedCell struct {
+	cellID   s2.CellID
+	distance s1.Angle
+}
+
+// cellQueue is a heap of the cells still to visit, nearest first
+type cellQueue []queuedCell
+
+func (q cellQueue) Len() int            { return len(q) }
+func (q cellQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q cellQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q *cellQueue) Push(x interface{}) { *q = append(*q, x.(queuedCell)) }
+func (q *cellQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
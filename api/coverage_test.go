@@ -0,0 +1,78 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/golang/geo/s2"
+)
+
+func TestRadiusCoverage(t *testing.T) {
+	l := &Location{Lat: 59.3293, Lon: 18.0686}
+	origin := uint64(s2.CellIDFromLatLng(s2.LatLngFromDegrees(l.Lat, l.Lon)).Parent(cellIDLevel))
+
+	tests := []struct {
+		radius   float64
+		maxCells int
+		min, max int
+	}{
+		{0, 0, 1, 1},
+		{500, 0, 4, 16},
+		{1000, 0, 30, 70},
+		{1000, 5, 5, 5},
+	}
+	for _, test := range tests {
+		cellIDs := (&RadiusCoverage{Radius: test.radius, MaxCells: test.maxCells}).CellIDs(l)
+		if len(cellIDs) < test.min || len(cellIDs) > test.max {
+			t.Errorf("radius %.0f: expected between %d and %d cells, got %d", test.radius, test.min, test.max, len(cellIDs))
+		}
+		if cellIDs[0] != origin {
+			t.Errorf("radius %.0f: expected the origin cell first", test.radius)
+		}
+		for _, cellID := range cellIDs {
+			if level := s2.CellID(cellID).Level(); level != cellIDLevel {
+				t.Errorf("radius %.0f: expected level %d cells, got %d", test.radius, cellIDLevel, level)
+			}
+		}
+	}
+}
+
+func TestRadiusCoverageCap(t *testing.T) {
+	l := &Location{Lat: 59.3293, Lon: 18.0686}
+
+	// The nearest cells are kept when the cap is reached
+	all := l.GetCellIDsWithinRadius(1000, cellIDLevel, 0)
+	capped := l.GetCellIDsWithinRadius(1000, cellIDLevel, 5)
+	for i, cellID := range capped {
+		if cellID != all[i] {
+			t.Errorf("expected cell %d to be %d, got %d", i, all[i], cellID)
+		}
+	}
+
+	// Hundreds of millions of level 20 cells are within the radius, the search stops at the cap
+	if cellIDs := l.GetCellIDsWithinRadius(100000, 20, 10); len(cellIDs) != 10 {
+		t.Errorf("expected 10 cells, got %d", len(cellIDs))
+	}
+}
+
+func TestRingCoverage(t *testing.T) {
+	l := &Location{Lat: 59.3293, Lon: 18.0686}
+
+	tests := []struct {
+		rings, level, maxCells int
+		expected               int
+	}{
+		{0, 15, 0, 1},
+		{1, 15, 0, 9},
+		{2, 15, 0, 25},
+		{2, 17, 10, 10},
+	}
+	for _, test := range tests {
+		cellIDs := (&RingCoverage{Rings: test.rings, Level: test.level, MaxCells: test.maxCells}).CellIDs(l)
+		if len(cellIDs) != test.expected {
+			t.Errorf("%d rings: expected %d cells, got %d", test.rings, test.expected, len(cellIDs))
+		}
+		if level := s2.CellID(cellIDs[0]).Level(); level != test.level {
+			t.Errorf("%d rings: expected level %d, got %d", test.rings, test.level, level)
+		}
+	}
+}
@@ -20,7 +20,7 @@ func (c *DefaultCrypto) Encrypt(in []byte, iv []byte) ([]byte, error) {
 }
 
 // CreateIV creates a random initialization vector to be used with the Encrypt function
-func (c *DefaultCrypto) CreateIV() []byte {
+func (c *DefaultCrypto) CreateIV(timestampSinceStart uint32) []byte {
 	iv := make([]byte, 32)
 	return iv
 }
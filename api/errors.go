@@ -40,6 +40,9 @@ var ErrRequest = errors.New("The remote service responded but the request could
 // ErrNoURL happens when the remote service is expected to respond with a remote URL but doesn't
 var ErrNoURL = errors.New("The remote service did not respond with a remote URL when expected")
 
+// ErrEmptyResponse happens when the remote service responds without the expected returns
+var ErrEmptyResponse = errors.New("The remote service responded without any returns")
+
 // ErrProxyDead happens when the provided proxy does not respond.
 var ErrProxyDead = errors.New("Dead proxy")
 
@@ -49,6 +52,21 @@ var ErrAccountBanned = errors.New("Account is banned")
 // ErrIpSoftBanned happens when a request is sent from a soft banned ip
 var ErrIpSoftBanned = errors.New("IP is softbanned")
 
+// ErrBattleEnded happens when an action is submitted to a gym battle that is no longer active
+var ErrBattleEnded = errors.New("The gym battle has already ended")
+
+// ErrBattleActive happens when the results of a gym battle are requested before it has ended
+var ErrBattleActive = errors.New("The gym battle is still active")
+
+// ErrUnknownInventory happens when an item is used before the inventory has been retrieved
+var ErrUnknownInventory = errors.New("The inventory has not been retrieved yet")
+
+// ErrItemNotInInventory happens when an item is used that is not present in the last known inventory
+var ErrItemNotInInventory = errors.New("The item is not present in the inventory")
+
+// ErrInvalidRoute happens when a walker is started without a route, speed or update interval
+var ErrInvalidRoute = errors.New("A route with a positive speed and update interval is required to walk")
+
 // GetErrorFromStatus will, depending on the status code, give you an error or nil if there is no error
 func GetErrorFromStatus(status protos.ResponseEnvelope_StatusCode) error {
 	switch status {
@@ -81,3 +99,12 @@ type ErrResponse struct {
 func (e *ErrResponse) Error() string {
 	return fmt.Sprintf("The response could not be read: %s", e.err.Error())
 }
+
+// ErrResult happens when the remote service responds with an unsuccessful result code
+type ErrResult struct {
+	Result fmt.Stringer
+}
+
+func (e *ErrResult) Error() string {
+	return fmt.Sprintf("The request was not successful: %s", e.Result.String())
+}
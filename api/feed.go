@@ -1,5 +1,17 @@
 package api
 
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
 // Feed is a common interface to act on encountered
 type Feed interface {
 	// Push is used to put response messages on to the feed
@@ -14,3 +26,274 @@ type VoidFeed struct {
 func (f *VoidFeed) Push(entry interface{}) {
 	// NOOP
 }
+
+// FeedEntry is a response message with the details of the call that produced it
+type FeedEntry struct {
+	Timestamp time.Time
+	// AccountID identifies the account of the session, see Session.SetAccountID
+	AccountID string
+	// Location is where the player was when the call was made
+	Location    Location
+	RequestType protos.RequestType
+	// StatusCode is the status code of the response envelope
+	StatusCode protos.ResponseEnvelope_StatusCode
+	Latency    time.Duration
+	Message    proto.Message
+}
+
+// entryJSON is the JSON object written for every entry by the file and webhook feeds
+type entryJSON struct {
+	Timestamp   *time.Time      `json:"timestamp,omitempty"`
+	AccountID   string          `json:"account_id,omitempty"`
+	Location    *locationJSON   `json:"location,omitempty"`
+	RequestType string          `json:"request_type,omitempty"`
+	StatusCode  string          `json:"status_code,omitempty"`
+	LatencyMs   float64         `json:"latency_ms,omitempty"`
+	Type        string          `json:"type"`
+	Message     json.RawMessage `json:"message"`
+}
+
+type locationJSON struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Alt      float64 `json:"alt"`
+	Accuracy float64 `json:"accuracy,omitempty"`
+}
+
+// eventJSON is the JSON object written for values pushed to the file and webhook feeds that are not
+// messages, like map events
+type eventJSON struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Type      string      `json:"type"`
+	Event     interface{} `json:"event"`
+}
+
+// MarshalJSON encodes the entry as the JSON object written by the file and webhook feeds,
+// leaving out the timestamp when the entry has none
+func (e *FeedEntry) MarshalJSON() ([]byte, error) {
+	return MarshalEntry(e, time.Time{})
+}
+
+// MarshalEntry encodes an entry as the JSON object written by the file and webhook feeds,
+// entries without details are given the time now unless it is zero
+func MarshalEntry(entry *FeedEntry, now time.Time) ([]byte, error) {
+	message, err := (&jsonpb.Marshaler{}).MarshalToString(entry.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	line := entryJSON{
+		AccountID: entry.AccountID,
+		Type:      proto.MessageName(entry.Message),
+		Message:   json.RawMessage(message),
+	}
+	if !entry.Timestamp.IsZero() {
+		timestamp := entry.Timestamp
+		line.Timestamp = &timestamp
+		line.Location = &locationJSON{
+			Lat:      entry.Location.Lat,
+			Lon:      entry.Location.Lon,
+			Alt:      entry.Location.Alt,
+			Accuracy: entry.Location.Accuracy,
+		}
+		line.RequestType = entry.RequestType.String()
+		line.StatusCode = entry.StatusCode.String()
+		line.LatencyMs = entry.Latency.Seconds() * 1000
+	} else if !now.IsZero() {
+		line.Timestamp = &now
+	}
+
+	return json.Marshal(line)
+}
+
+// MarshalEvent encodes a value that is not a message as the JSON object written by the file and webhook feeds,
+// typed by its Kind method if it has one
+func MarshalEvent(event interface{}, now time.Time) ([]byte, error) {
+	eventType := fmt.Sprintf("%T", event)
+	if k, ok := event.(interface {
+		Kind() string
+	}); ok {
+		eventType = k.Kind()
+	}
+	return json.Marshal(eventJSON{Timestamp: now, Type: eventType, Event: event})
+}
+
+// EntryFeed is a common interface for feeds receiving responses with the details of their call
+//
+// Feeds passed to NewSession that also implement EntryFeed receive entries instead of messages.
+type EntryFeed interface {
+	PushEntry(entry *FeedEntry)
+}
+
+// FeedAdapter passes the messages of feed entries on to a feed that only takes messages
+type FeedAdapter struct {
+	Feed Feed
+}
+
+// PushEntry pushes the message of the entry to the feed
+func (a *FeedAdapter) PushEntry(entry *FeedEntry) {
+	a.Feed.Push(entry.Message)
+}
+
+// entryFeed returns the feed itself when it takes entries, or else an adapter pushing only the messages
+func entryFeed(feed Feed) EntryFeed {
+	if f, ok := feed.(EntryFeed); ok {
+		return f
+	}
+	return &FeedAdapter{Feed: feed}
+}
+
+// deliver passes an entry on to a feed, as a whole if the feed takes entries or else only its message
+func deliver(feed Feed, entry interface{}) {
+	if e, ok := entry.(*FeedEntry); ok {
+		if f, ok := feed.(EntryFeed); ok {
+			f.PushEntry(e)
+			return
+		}
+		feed.Push(e.Message)
+		return
+	}
+	feed.Push(entry)
+}
+
+// MultiFeed is a feed passing every entry on to all of its feeds in order
+type MultiFeed struct {
+	Feeds []Feed
+}
+
+// NewMultiFeed constructs a feed fanning out to the feeds
+func NewMultiFeed(feeds ...Feed) *MultiFeed {
+	return &MultiFeed{Feeds: feeds}
+}
+
+// Push passes the message on to all feeds
+func (f *MultiFeed) Push(entry interface{}) {
+	for _, feed := range f.Feeds {
+		feed.Push(entry)
+	}
+}
+
+// PushEntry passes the entry on to all feeds
+func (f *MultiFeed) PushEntry(entry *FeedEntry) {
+	for _, feed := range f.Feeds {
+		deliver(feed, entry)
+	}
+}
+
+// FilterFeed is a feed only passing on the entries a predicate accepts
+//
+// Messages pushed without details are given to the predicate as entries with only the message set,
+// and values that are not messages are always passed on.
+type FilterFeed struct {
+	Feed      Feed
+	Predicate func(entry *FeedEntry) bool
+}
+
+// NewFilterFeed constructs a feed passing the entries accepted by the predicate on to the feed
+func NewFilterFeed(feed Feed, predicate func(entry *FeedEntry) bool) *FilterFeed {
+	return &FilterFeed{
+		Feed:      feed,
+		Predicate: predicate,
+	}
+}
+
+// Push passes the message on if the predicate accepts it
+func (f *FilterFeed) Push(entry interface{}) {
+	if message, ok := entry.(proto.Message); ok && !f.Predicate(&FeedEntry{Message: message}) {
+		return
+	}
+	f.Feed.Push(entry)
+}
+
+// PushEntry passes the entry on if the predicate accepts it
+func (f *FilterFeed) PushEntry(entry *FeedEntry) {
+	if f.Predicate(entry) {
+		deliver(f.Feed, entry)
+	}
+}
+
+// TypedFeed is a feed passing every response to the handlers registered for its type
+type TypedFeed struct {
+	mutex     sync.RWMutex
+	handlers  map[reflect.Type][]func(proto.Message)
+	unhandled []func(interface{})
+}
+
+// NewTypedFeed constructs a feed without any handlers
+func NewTypedFeed() *TypedFeed {
+	return &TypedFeed{
+		handlers: make(map[reflect.Type][]func(proto.Message)),
+	}
+}
+
+// On registers a handler for responses of the same type as the message
+func (f *TypedFeed) On(message proto.Message, handler func(proto.Message)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := reflect.TypeOf(message)
+	f.handlers[t] = append(f.handlers[t], handler)
+}
+
+// OnUnhandled registers a handler for all entries without a handler for their type
+func (f *TypedFeed) OnUnhandled(handler func(interface{})) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.unhandled = append(f.unhandled, handler)
+}
+
+// OnMapObjects registers a handler for map responses
+func (f *TypedFeed) OnMapObjects(handler func(*protos.GetMapObjectsResponse)) {
+	f.On(&protos.GetMapObjectsResponse{}, func(m proto.Message) {
+		handler(m.(*protos.GetMapObjectsResponse))
+	})
+}
+
+// OnInventory registers a handler for inventory responses
+func (f *TypedFeed) OnInventory(handler func(*protos.GetInventoryResponse)) {
+	f.On(&protos.GetInventoryResponse{}, func(m proto.Message) {
+		handler(m.(*protos.GetInventoryResponse))
+	})
+}
+
+// OnPlayer registers a handler for player responses
+func (f *TypedFeed) OnPlayer(handler func(*protos.GetPlayerResponse)) {
+	f.On(&protos.GetPlayerResponse{}, func(m proto.Message) {
+		handler(m.(*protos.GetPlayerResponse))
+	})
+}
+
+// OnHatchedEggs registers a handler for hatched egg responses
+func (f *TypedFeed) OnHatchedEggs(handler func(*protos.GetHatchedEggsResponse)) {
+	f.On(&protos.GetHatchedEggsResponse{}, func(m proto.Message) {
+		handler(m.(*protos.GetHatchedEggsResponse))
+	})
+}
+
+// OnBuddyWalked registers a handler for buddy candy responses
+func (f *TypedFeed) OnBuddyWalked(handler func(*protos.GetBuddyWalkedResponse)) {
+	f.On(&protos.GetBuddyWalkedResponse{}, func(m proto.Message) {
+		handler(m.(*protos.GetBuddyWalkedResponse))
+	})
+}
+
+// Push passes the entry to the handlers for its type, in the order they were registered
+func (f *TypedFeed) Push(entry interface{}) {
+	f.mutex.RLock()
+	handlers := f.handlers[reflect.TypeOf(entry)]
+	unhandled := f.unhandled
+	f.mutex.RUnlock()
+
+	message, ok := entry.(proto.Message)
+	if !ok || len(handlers) == 0 {
+		for _, handler := range unhandled {
+			handler(entry)
+		}
+		return
+	}
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+}
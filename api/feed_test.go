@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestTypedFeed(t *testing.T) {
+	feed := NewTypedFeed()
+	received := make([]string, 0)
+
+	feed.OnMapObjects(func(m *protos.GetMapObjectsResponse) {
+		received = append(received, "map")
+	})
+	feed.OnHatchedEggs(func(m *protos.GetHatchedEggsResponse) {
+		received = append(received, "eggs")
+	})
+	feed.On(&protos.GetHatchedEggsResponse{}, func(m proto.Message) {
+		received = append(received, "eggs again")
+	})
+	feed.OnUnhandled(func(entry interface{}) {
+		received = append(received, "unhandled")
+	})
+
+	feed.Push(&protos.GetMapObjectsResponse{})
+	feed.Push(&protos.GetHatchedEggsResponse{})
+	feed.Push(&protos.GetPlayerResponse{})
+	feed.Push("not a message")
+
+	expected := []string{"map", "eggs", "eggs again", "unhandled", "unhandled"}
+	if len(received) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, received)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, received)
+			break
+		}
+	}
+}
+
+type entryRecorder struct {
+	entries []*FeedEntry
+}
+
+func (f *entryRecorder) PushEntry(entry *FeedEntry) {
+	f.entries = append(f.entries, entry)
+}
+
+type messageRecorder struct {
+	messages []interface{}
+}
+
+func (f *messageRecorder) Push(entry interface{}) {
+	f.messages = append(f.messages, entry)
+}
+
+func TestFeedEntries(t *testing.T) {
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.GetPlayerResponse{Success: true}},
+		[]proto.Message{&protos.GetPlayerResponse{Success: true}},
+	)
+	defer server.Close()
+
+	location := &Location{Lat: 59.33, Lon: 18.06}
+	feed := &entryRecorder{}
+	session := NewSession(&testProvider{}, location, &VoidFeed{}, &testCrypto{}, false)
+	session.SetEntryFeed(feed)
+	session.url = server.URL
+	session.SetAccountID("ptc:ash")
+
+	if _, err := session.GetPlayer(context.Background(), -1); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(feed.entries))
+	}
+	entry := feed.entries[0]
+	if entry.AccountID != "ptc:ash" || entry.RequestType != protos.RequestType_GET_PLAYER || entry.StatusCode != protos.ResponseEnvelope_OK {
+		t.Errorf("expected the details of the call, got %v", entry)
+	}
+	if entry.Location != *location || entry.Timestamp.IsZero() || entry.Latency <= 0 {
+		t.Errorf("expected the location and timing of the call, got %v", entry)
+	}
+	if _, ok := entry.Message.(*protos.GetPlayerResponse); !ok {
+		t.Errorf("expected the player response, got %v", entry.Message)
+	}
+
+	// Feeds only taking messages keep working
+	messages := &messageRecorder{}
+	session = NewSession(&testProvider{}, location, messages, &testCrypto{}, false)
+	session.url = server.URL
+	if _, err := session.GetPlayer(context.Background(), -1); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages.messages))
+	}
+	if _, ok := messages.messages[0].(*protos.GetPlayerResponse); !ok {
+		t.Errorf("expected the player response, got %v", messages.messages[0])
+	}
+}
+
+func TestMultiAndFilterFeeds(t *testing.T) {
+	entries := &entryRecorder{}
+	messages := &messageRecorder{}
+	players := NewFilterFeed(messages, func(entry *FeedEntry) bool {
+		_, ok := entry.Message.(*protos.GetPlayerResponse)
+		return ok
+	})
+	entryFeed := struct {
+		Feed
+		EntryFeed
+	}{&VoidFeed{}, entries}
+	feed := NewMultiFeed(players, entryFeed)
+
+	feed.PushEntry(&FeedEntry{Message: &protos.GetPlayerResponse{}, AccountID: "ptc:ash"})
+	feed.PushEntry(&FeedEntry{Message: &protos.GetMapObjectsResponse{}})
+	feed.Push(&protos.GetPlayerResponse{})
+
+	if len(messages.messages) != 2 {
+		t.Errorf("expected the filter to pass on 2 player messages, got %d", len(messages.messages))
+	}
+	if len(entries.entries) != 2 || entries.entries[0].AccountID != "ptc:ash" {
+		t.Errorf("expected the entry feed to receive whole entries, got %v", entries.entries)
+	}
+}
+
+func TestFeedEntryMarshalJSON(t *testing.T) {
+	entry := &FeedEntry{Message: &protos.GetPlayerResponse{Success: true}}
+	first, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("expected the same encoding twice, got %s and %s", first, second)
+	}
+	if strings.Contains(string(first), "timestamp") {
+		t.Errorf("expected no timestamp for an entry without one, got %s", first)
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"math"
+)
+
+// LatLng is a common interface for anything with coordinates, like a Location
+// or the forts, spawn points and Pokémon found in map objects
+type LatLng interface {
+	GetLatitude() float64
+	GetLongitude() float64
+}
+
+// GetLatitude returns the latitude of the location in degrees
+func (l *Location) GetLatitude() float64 {
+	return l.Lat
+}
+
+// GetLongitude returns the longitude of the location in degrees
+func (l *Location) GetLongitude() float64 {
+	return l.Lon
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// normalizeLongitude wraps a longitude in to the range [-180, 180)
+func normalizeLongitude(lon float64) float64 {
+	return math.Mod(lon+540, 360) - 180
+}
+
+// Distance returns the great circle distance in metres between two coordinates using the Haversine formula
+// Reference: https://gist.github.com/cdipaolo/d3f8db3848278b49db68
+func Distance(a, b LatLng) float64 {
+	la1, lo1 := radians(a.GetLatitude()), radians(a.GetLongitude())
+	la2, lo2 := radians(b.GetLatitude()), radians(b.GetLongitude())
+
+	dla := math.Sin(0.5 * (la2 - la1))
+	dlo := math.Sin(0.5 * (lo2 - lo1))
+	h := dla*dla + math.Cos(la1)*math.Cos(la2)*dlo*dlo
+
+	return 2 * earthRadiusInMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing returns the initial bearing in degrees clockwise from north, in the range [0, 360),
+// to follow the great circle from the first to the second coordinate
+func Bearing(a, b LatLng) float64 {
+	la1, lo1 := radians(a.GetLatitude()), radians(a.GetLongitude())
+	la2, lo2 := radians(b.GetLatitude()), radians(b.GetLongitude())
+
+	y := math.Sin(lo2-lo1) * math.Cos(la2)
+	x := math.Cos(la1)*math.Sin(la2) - math.Sin(la1)*math.Cos(la2)*math.Cos(lo2-lo1)
+
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// DistanceTo returns the distance in metres from the location to the coordinates
+func (l *Location) DistanceTo(p LatLng) float64 {
+	return Distance(l, p)
+}
+
+// BearingTo returns the initial bearing in degrees from the location to the coordinates
+func (l *Location) BearingTo(p LatLng) float64 {
+	return Bearing(l, p)
+}
+
+// Destination returns the location reached by travelling a distance in metres along the great circle
+// starting at the given bearing in degrees, keeping the altitude and accuracy
+func (l *Location) Destination(bearing, distance float64) *Location {
+	la1, lo1 := radians(l.Lat), radians(l.Lon)
+	b := radians(bearing)
+	d := distance / earthRadiusInMeters
+
+	la2 := math.Asin(math.Sin(la1)*math.Cos(d) + math.Cos(la1)*math.Sin(d)*math.Cos(b))
+	lo2 := lo1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(la1), math.Cos(d)-math.Sin(la1)*math.Sin(la2))
+
+	return &Location{
+		Lat:      degrees(la2),
+		Lon:      normalizeLongitude(degrees(lo2)),
+		Alt:      l.Alt,
+		Accuracy: l.Accuracy,
+	}
+}
+
+// Interpolate returns the location at a fraction of the way along the great circle to the coordinates,
+// where 0 is the location itself and 1 is the destination, keeping the altitude and accuracy
+func (l *Location) Interpolate(to LatLng, fraction float64) *Location {
+	la1, lo1 := radians(l.Lat), radians(l.Lon)
+	la2, lo2 := radians(to.GetLatitude()), radians(to.GetLongitude())
+
+	d := Distance(l, to) / earthRadiusInMeters
+	if d == 0 {
+		return &Location{Lat: l.Lat, Lon: l.Lon, Alt: l.Alt, Accuracy: l.Accuracy}
+	}
+
+	a := math.Sin((1-fraction)*d) / math.Sin(d)
+	b := math.Sin(fraction*d) / math.Sin(d)
+	x := a*math.Cos(la1)*math.Cos(lo1) + b*math.Cos(la2)*math.Cos(lo2)
+	y := a*math.Cos(la1)*math.Sin(lo1) + b*math.Cos(la2)*math.Sin(lo2)
+	z := a*math.Sin(la1) + b*math.Sin(la2)
+
+	return &Location{
+		Lat:      degrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Lon:      degrees(math.Atan2(y, x)),
+		Alt:      l.Alt,
+		Accuracy: l.Accuracy,
+	}
+}
+
+// BoundingBox is an area between two latitudes and two longitudes in degrees
+//
+// A box crossing the antimeridian has a MinLon greater than its MaxLon.
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// NewBoundingBox returns the smallest box containing all coordinates, not taking the antimeridian in to account
+func NewBoundingBox(points ...LatLng) BoundingBox {
+	if len(points) == 0 {
+		return BoundingBox{}
+	}
+	b := BoundingBox{
+		MinLat: points[0].GetLatitude(),
+		MinLon: points[0].GetLongitude(),
+		MaxLat: points[0].GetLatitude(),
+		MaxLon: points[0].GetLongitude(),
+	}
+	for _, p := range points[1:] {
+		b.MinLat = math.Min(b.MinLat, p.GetLatitude())
+		b.MinLon = math.Min(b.MinLon, p.GetLongitude())
+		b.MaxLat = math.Max(b.MaxLat, p.GetLatitude())
+		b.MaxLon = math.Max(b.MaxLon, p.GetLongitude())
+	}
+	return b
+}
+
+// BoundingBox returns the box containing every point within a radius in metres of the location
+func (l *Location) BoundingBox(radius float64) BoundingBox {
+	d := radius / earthRadiusInMeters
+	la := radians(l.Lat)
+
+	b := BoundingBox{
+		MinLat: degrees(la - d),
+		MaxLat: degrees(la + d),
+	}
+
+	// The box reaches over a pole, so it has to include every longitude
+	if b.MinLat <= -90 || b.MaxLat >= 90 {
+		b.MinLat = math.Max(b.MinLat, -90)
+		b.MaxLat = math.Min(b.MaxLat, 90)
+		b.MinLon = -180
+		b.MaxLon = 180
+		return b
+	}
+
+	dlo := degrees(math.Asin(math.Sin(d) / math.Cos(la)))
+	b.MinLon = normalizeLongitude(l.Lon - dlo)
+	b.MaxLon = normalizeLongitude(l.Lon + dlo)
+	return b
+}
+
+// Contains returns whether or not the coordinates are inside the box
+func (b BoundingBox) Contains(p LatLng) bool {
+	lat, lon := p.GetLatitude(), p.GetLongitude()
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// Center returns the location in the middle of the box
+func (b BoundingBox) Center() *Location {
+	maxLon := b.MaxLon
+	if b.MinLon > maxLon {
+		maxLon += 360
+	}
+	return &Location{
+		Lat: (b.MinLat + b.MaxLat) / 2,
+		Lon: normalizeLongitude((b.MinLon + maxLon) / 2),
+	}
+}
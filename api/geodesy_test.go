@@ -0,0 +1,142 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+var geodesyTests = []struct {
+	name     string
+	from, to *Location
+	distance float64
+	bearing  float64
+}{
+	{"one degree east along the equator", &Location{Lat: 0, Lon: 0}, &Location{Lat: 0, Lon: 1}, 111318.8, 90},
+	{"one degree north along the meridian", &Location{Lat: 0, Lon: 0}, &Location{Lat: 1, Lon: 0}, 111318.8, 0},
+	{"London to Paris", &Location{Lat: 51.5074, Lon: -0.1278}, &Location{Lat: 48.8566, Lon: 2.3522}, 343938.9, 148.1156},
+	{"Baghdad to Osaka", &Location{Lat: 35, Lon: 45}, &Location{Lat: 35, Lon: 135}, 7880541.6, 60.1624},
+	{"Stockholm to Helsinki", &Location{Lat: 59.3293, Lon: 18.0686}, &Location{Lat: 60.1699, Lon: 24.9384}, 396260.7, 73.3957},
+	{"across the antimeridian", &Location{Lat: 0, Lon: 179.5}, &Location{Lat: 0, Lon: -179.5}, 111318.8, 90},
+}
+
+func TestDistanceAndBearing(t *testing.T) {
+	for _, test := range geodesyTests {
+		if d := test.from.DistanceTo(test.to); math.Abs(d-test.distance) > 0.5 {
+			t.Errorf("%s: expected distance %.1f, got %.1f", test.name, test.distance, d)
+		}
+		if b := test.from.BearingTo(test.to); math.Abs(b-test.bearing) > 0.001 {
+			t.Errorf("%s: expected bearing %.4f, got %.4f", test.name, test.bearing, b)
+		}
+	}
+}
+
+func TestDestination(t *testing.T) {
+	for _, test := range geodesyTests {
+		destination := test.from.Destination(test.from.BearingTo(test.to), test.from.DistanceTo(test.to))
+		if d := destination.DistanceTo(test.to); d > 0.01 {
+			t.Errorf("%s: expected to arrive at %v, got %v which is %.1f metres off", test.name, test.to, destination, d)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		fraction float64
+		expected *Location
+	}{
+		{0, &Location{Lat: 0, Lon: 0}},
+		{0.5, &Location{Lat: 0, Lon: 5}},
+		{1, &Location{Lat: 0, Lon: 10}},
+	}
+	from := &Location{Lat: 0, Lon: 0, Alt: 12, Accuracy: 3}
+	for _, test := range tests {
+		l := from.Interpolate(&Location{Lat: 0, Lon: 10}, test.fraction)
+		if d := l.DistanceTo(test.expected); d > 0.01 || l.Alt != 12 || l.Accuracy != 3 {
+			t.Errorf("fraction %.1f: expected %v, got %v", test.fraction, test.expected, l)
+		}
+	}
+
+	for _, test := range geodesyTests {
+		middle := test.from.Interpolate(test.to, 0.5)
+		if d := math.Abs(middle.DistanceTo(test.from) - middle.DistanceTo(test.to)); d > 0.5 {
+			t.Errorf("%s: expected the middle to be equally far from both ends, off by %.1f metres", test.name, d)
+		}
+	}
+}
+
+func TestDistanceToMapObjects(t *testing.T) {
+	l := &Location{Lat: 0, Lon: 0}
+	tests := []struct {
+		name     string
+		p        LatLng
+		distance float64
+	}{
+		{"fort", &protos.FortData{Latitude: 0, Longitude: 1}, 111318.8},
+		{"wild Pokémon", &protos.WildPokemon{Latitude: 1, Longitude: 0}, 111318.8},
+		{"spawn point", &protos.SpawnPoint{Latitude: 0, Longitude: 0}, 0},
+	}
+	for _, test := range tests {
+		if d := l.DistanceTo(test.p); math.Abs(d-test.distance) > 0.5 {
+			t.Errorf("%s: expected distance %.1f, got %.1f", test.name, test.distance, d)
+		}
+	}
+	if d := l.DistanceToFort(&protos.FortData{Latitude: 0, Longitude: 1}); math.Abs(d-111318.8) > 0.5 {
+		t.Errorf("expected fort distance 111318.8, got %.1f", d)
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		center   *Location
+		radius   float64
+		inside   []*Location
+		outside  []*Location
+		wrapping bool
+	}{
+		{
+			"equator", &Location{Lat: 0, Lon: 0}, 111318.8,
+			[]*Location{{Lat: 0.99, Lon: 0}, {Lat: 0, Lon: -0.99}},
+			[]*Location{{Lat: 1.01, Lon: 0}, {Lat: 0, Lon: 1.01}},
+			false,
+		},
+		{
+			"antimeridian", &Location{Lat: 0, Lon: 179.9}, 111318.8,
+			[]*Location{{Lat: 0, Lon: -179.5}, {Lat: 0, Lon: 179}},
+			[]*Location{{Lat: 0, Lon: -178.5}, {Lat: 0, Lon: 178.5}},
+			true,
+		},
+		{
+			"pole", &Location{Lat: 89.5, Lon: 0}, 111318.8,
+			[]*Location{{Lat: 90, Lon: 0}, {Lat: 89, Lon: 180}},
+			[]*Location{{Lat: 88, Lon: 0}},
+			false,
+		},
+	}
+	for _, test := range tests {
+		b := test.center.BoundingBox(test.radius)
+		for _, l := range test.inside {
+			if !b.Contains(l) {
+				t.Errorf("%s: expected %v to contain %v", test.name, b, l)
+			}
+		}
+		for _, l := range test.outside {
+			if b.Contains(l) {
+				t.Errorf("%s: expected %v not to contain %v", test.name, b, l)
+			}
+		}
+		if wrapping := b.MinLon > b.MaxLon; wrapping != test.wrapping {
+			t.Errorf("%s: expected wrapping to be %t for %v", test.name, test.wrapping, b)
+		}
+	}
+
+	b := NewBoundingBox(&Location{Lat: 1, Lon: 2}, &protos.SpawnPoint{Latitude: -1, Longitude: 4})
+	if b != (BoundingBox{MinLat: -1, MinLon: 2, MaxLat: 1, MaxLon: 4}) {
+		t.Errorf("unexpected bounding box %v", b)
+	}
+	if center := b.Center(); center.Lat != 0 || center.Lon != 3 {
+		t.Errorf("unexpected center %v", center)
+	}
+}
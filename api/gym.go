@@ -0,0 +1,160 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// damageWindowMs is the time before the end of an attack in which the damage is dealt
+const damageWindowMs = 200
+
+// GymBattle keeps track of a battle against the defenders of a gym
+type GymBattle struct {
+	session *Session
+	gymID   string
+	id      string
+
+	state    protos.BattleState
+	serverMs int64
+	attacker *protos.BattlePokemonInfo
+	defender *protos.BattlePokemonInfo
+
+	lastAction *protos.BattleAction
+	results    *protos.BattleResults
+}
+
+// StartGymBattle starts a battle against the given defender of a gym using the attacking team
+func (s *Session) StartGymBattle(ctx context.Context, gymID string, attackingPokemonIDs []uint64, defendingPokemonID uint64, proxyId int64) (*GymBattle, error) {
+	location := s.currentLocation()
+	response := &protos.StartGymBattleResponse{}
+	err := s.callSingle(ctx, protos.RequestType_START_GYM_BATTLE, &protos.StartGymBattleMessage{
+		GymId:               gymID,
+		AttackingPokemonIds: attackingPokemonIDs,
+		DefendingPokemonId:  defendingPokemonID,
+		PlayerLatitude:      location.Lat,
+		PlayerLongitude:     location.Lon,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.StartGymBattleResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+
+	battle := &GymBattle{
+		session:  s,
+		gymID:    gymID,
+		id:       response.BattleId,
+		state:    protos.BattleState_ACTIVE,
+		attacker: response.GetAttacker().GetActivePokemon(),
+		defender: response.GetDefender().GetActivePokemon(),
+	}
+	battle.update(response.BattleLog)
+
+	return battle, nil
+}
+
+// Attack submits the attack actions to the battle and updates the battle state from the response
+func (b *GymBattle) Attack(ctx context.Context, actions []*protos.BattleAction, proxyId int64) (*protos.AttackGymResponse, error) {
+	if !b.IsActive() {
+		return nil, ErrBattleEnded
+	}
+
+	location := b.session.currentLocation()
+	response := &protos.AttackGymResponse{}
+	err := b.session.callSingle(ctx, protos.RequestType_ATTACK_GYM, &protos.AttackGymMessage{
+		GymId:               b.gymID,
+		BattleId:            b.id,
+		AttackActions:       actions,
+		LastRetrievedAction: b.lastAction,
+		PlayerLatitude:      location.Lat,
+		PlayerLongitude:     location.Lon,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.AttackGymResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+
+	if response.ActiveAttacker != nil {
+		b.attacker = response.ActiveAttacker
+	}
+	if response.ActiveDefender != nil {
+		b.defender = response.ActiveDefender
+	}
+	b.update(response.BattleLog)
+
+	return response, nil
+}
+
+func (b *GymBattle) update(log *protos.BattleLog) {
+	if log == nil {
+		return
+	}
+	if log.State != protos.BattleState_STATE_UNSET {
+		b.state = log.State
+	}
+	if log.ServerMs > 0 {
+		b.serverMs = int64(log.ServerMs)
+	}
+	for _, action := range log.BattleActions {
+		b.lastAction = action
+		if action.BattleResults != nil {
+			b.results = action.BattleResults
+		}
+	}
+}
+
+// NewAttackAction creates a basic attack starting at the given battle server time
+func (b *GymBattle) NewAttackAction(startMs int64, durationMs int32) *protos.BattleAction {
+	end := startMs + int64(durationMs)
+	return &protos.BattleAction{
+		Type:                           protos.BattleActionType_ACTION_ATTACK,
+		ActionStartMs:                  startMs,
+		DurationMs:                     durationMs,
+		TargetIndex:                    -1,
+		ActivePokemonId:                b.attacker.GetPokemonData().GetId(),
+		DamageWindowsStartTimestampMss: end - damageWindowMs,
+		DamageWindowsEndTimestampMss:   end,
+	}
+}
+
+// ID returns the identifier the remote service assigned to the battle
+func (b *GymBattle) ID() string {
+	return b.id
+}
+
+// State returns the last known state of the battle
+func (b *GymBattle) State() protos.BattleState {
+	return b.state
+}
+
+// IsActive returns whether or not the battle is still ongoing
+func (b *GymBattle) IsActive() bool {
+	return b.state == protos.BattleState_ACTIVE
+}
+
+// ServerMs returns the last known battle server time in milliseconds
+func (b *GymBattle) ServerMs() int64 {
+	return b.serverMs
+}
+
+// Attacker returns the currently active attacking Pokémon and its health
+func (b *GymBattle) Attacker() *protos.BattlePokemonInfo {
+	return b.attacker
+}
+
+// Defender returns the currently active defending Pokémon and its health
+func (b *GymBattle) Defender() *protos.BattlePokemonInfo {
+	return b.defender
+}
+
+// Results returns the outcome of the battle once it has ended
+func (b *GymBattle) Results() (*protos.BattleResults, error) {
+	if b.IsActive() {
+		return nil, ErrBattleActive
+	}
+	return b.results, nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestGymBattle(t *testing.T) {
+	attacker := &protos.BattlePokemonInfo{PokemonData: &protos.PokemonData{Id: 1}, CurrentHealth: 100}
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.StartGymBattleResponse{
+			Result:   protos.StartGymBattleResponse_SUCCESS,
+			BattleId: "battle",
+			Attacker: &protos.BattleParticipant{
+				ActivePokemon: &protos.BattlePokemonInfo{PokemonData: &protos.PokemonData{Id: 1}, CurrentHealth: 100},
+			},
+			Defender: &protos.BattleParticipant{
+				ActivePokemon: &protos.BattlePokemonInfo{PokemonData: &protos.PokemonData{Id: 9}, CurrentHealth: 80},
+			},
+			BattleLog: &protos.BattleLog{
+				State:    protos.BattleState_ACTIVE,
+				ServerMs: 1000,
+				BattleActions: []*protos.BattleAction{
+					{Type: protos.BattleActionType_ACTION_PLAYER_JOIN, ActionStartMs: 1000},
+				},
+			},
+		}},
+		[]proto.Message{&protos.AttackGymResponse{
+			Result:         protos.AttackGymResponse_SUCCESS,
+			ActiveAttacker: attacker,
+			ActiveDefender: &protos.BattlePokemonInfo{PokemonData: &protos.PokemonData{Id: 9}, CurrentHealth: 40},
+			BattleLog: &protos.BattleLog{
+				State:    protos.BattleState_ACTIVE,
+				ServerMs: 2000,
+			},
+		}},
+		[]proto.Message{&protos.AttackGymResponse{
+			Result:         protos.AttackGymResponse_SUCCESS,
+			ActiveDefender: &protos.BattlePokemonInfo{PokemonData: &protos.PokemonData{Id: 9}, CurrentHealth: 0},
+			BattleLog: &protos.BattleLog{
+				State:    protos.BattleState_VICTORY,
+				ServerMs: 3000,
+				BattleActions: []*protos.BattleAction{
+					{Type: protos.BattleActionType_ACTION_VICTORY, BattleResults: &protos.BattleResults{GymPointsDelta: -500}},
+				},
+			},
+		}},
+	)
+	defer server.Close()
+
+	ctx := context.Background()
+	battle, err := server.session().StartGymBattle(ctx, "gym", []uint64{1, 2}, 9, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := &protos.StartGymBattleMessage{}
+	server.lastMessage(start)
+	if start.GymId != "gym" || len(start.AttackingPokemonIds) != 2 || start.DefendingPokemonId != 9 {
+		t.Errorf("unexpected start message %v", start)
+	}
+	if battle.ID() != "battle" || battle.ServerMs() != 1000 || battle.Defender().CurrentHealth != 80 {
+		t.Errorf("unexpected battle after start: %s %d %v", battle.ID(), battle.ServerMs(), battle.Defender())
+	}
+	if _, err := battle.Results(); err != ErrBattleActive {
+		t.Errorf("expected results to be unavailable, got %v", err)
+	}
+
+	_, err = battle.Attack(ctx, []*protos.BattleAction{battle.NewAttackAction(battle.ServerMs(), 500)}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attack := &protos.AttackGymMessage{}
+	server.lastMessage(attack)
+	if attack.BattleId != "battle" || attack.LastRetrievedAction.GetType() != protos.BattleActionType_ACTION_PLAYER_JOIN {
+		t.Errorf("unexpected attack message %v", attack)
+	}
+	if action := attack.AttackActions[0]; action.ActivePokemonId != 1 {
+		t.Errorf("expected the first action to be by the active attacker, got %d", action.ActivePokemonId)
+	}
+	if action := attack.AttackActions[0]; action.DamageWindowsEndTimestampMss != 1500 || action.DamageWindowsStartTimestampMss != 1300 {
+		t.Errorf("unexpected attack action %v", action)
+	}
+	if battle.Attacker().CurrentHealth != 100 || battle.Defender().CurrentHealth != 40 {
+		t.Errorf("unexpected health %v %v", battle.Attacker(), battle.Defender())
+	}
+
+	_, err = battle.Attack(ctx, []*protos.BattleAction{battle.NewAttackAction(battle.ServerMs(), 500)}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if battle.IsActive() || battle.State() != protos.BattleState_VICTORY {
+		t.Errorf("expected battle to be won, got %s", battle.State())
+	}
+	results, err := battle.Results()
+	if err != nil || results.GymPointsDelta != -500 {
+		t.Errorf("unexpected results %v %v", results, err)
+	}
+	if _, err := battle.Attack(ctx, nil, -1); err != ErrBattleEnded {
+		t.Errorf("expected attack on ended battle to fail, got %v", err)
+	}
+}
+
+func TestGymBattleAttackFailure(t *testing.T) {
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.StartGymBattleResponse{
+			Result:   protos.StartGymBattleResponse_SUCCESS,
+			BattleId: "battle",
+			BattleLog: &protos.BattleLog{
+				State:    protos.BattleState_ACTIVE,
+				ServerMs: 1000,
+			},
+		}},
+		[]proto.Message{&protos.AttackGymResponse{Result: protos.AttackGymResponse_ERROR_NOT_IN_RANGE}},
+	)
+	defer server.Close()
+
+	ctx := context.Background()
+	battle, err := server.session().StartGymBattle(ctx, "gym", []uint64{1}, 9, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := battle.Attack(ctx, nil, -1)
+	if _, ok := err.(*ErrResult); !ok || response != nil {
+		t.Errorf("expected a result error and no response, got %v %v", response, err)
+	}
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"sort"
+	"sync"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// Inventory is a full view of the player inventory kept up to date by applying inventory deltas
+type Inventory struct {
+	mutex sync.RWMutex
+
+	seeded      bool
+	timestampMs int64
+
+	pokemon      map[uint64]*protos.PokemonData
+	items        map[protos.ItemId]int32
+	candies      map[protos.PokemonFamilyId]int32
+	pokedex      map[protos.PokemonId]*protos.PokedexEntry
+	incubators   []*protos.EggIncubator
+	appliedItems []*protos.AppliedItem
+	stats        *protos.PlayerStats
+}
+
+// NewInventory constructs an empty inventory that has not been seeded yet
+func NewInventory() *Inventory {
+	i := &Inventory{}
+	i.reset()
+	return i
+}
+
+func (i *Inventory) reset() {
+	i.seeded = false
+	i.timestampMs = 0
+	i.pokemon = make(map[uint64]*protos.PokemonData)
+	i.items = make(map[protos.ItemId]int32)
+	i.candies = make(map[protos.PokemonFamilyId]int32)
+	i.pokedex = make(map[protos.PokemonId]*protos.PokedexEntry)
+	i.incubators = nil
+	i.appliedItems = nil
+	i.stats = nil
+}
+
+// Seed replaces the contents of the inventory with a full inventory
+func (i *Inventory) Seed(delta *protos.InventoryDelta) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.reset()
+	i.apply(delta)
+	i.seeded = true
+}
+
+// Apply merges the changes of an inventory delta in to the inventory
+func (i *Inventory) Apply(delta *protos.InventoryDelta) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.apply(delta)
+}
+
+func (i *Inventory) apply(delta *protos.InventoryDelta) {
+	if delta == nil {
+		return
+	}
+
+	for _, item := range delta.InventoryItems {
+		if deleted := item.GetDeletedItem(); deleted != nil {
+			delete(i.pokemon, deleted.PokemonId)
+		}
+
+		data := item.GetInventoryItemData()
+		if data == nil {
+			continue
+		}
+		if pokemon := data.GetPokemonData(); pokemon != nil {
+			i.pokemon[pokemon.Id] = pokemon
+		}
+		if bagItem := data.GetItem(); bagItem != nil {
+			i.items[bagItem.ItemId] = bagItem.Count
+		}
+		if candy := data.GetCandy(); candy != nil {
+			i.candies[candy.FamilyId] = candy.Candy
+		}
+		if entry := data.GetPokedexEntry(); entry != nil {
+			i.pokedex[entry.PokemonId] = entry
+		}
+		if incubators := data.GetEggIncubators(); incubators != nil {
+			i.incubators = incubators.EggIncubator
+		}
+		if applied := data.GetAppliedItems(); applied != nil {
+			i.appliedItems = applied.Item
+		}
+		if stats := data.GetPlayerStats(); stats != nil {
+			i.stats = stats
+		}
+	}
+
+	if delta.NewTimestampMs > i.timestampMs {
+		i.timestampMs = delta.NewTimestampMs
+	}
+}
+
+// IsSeeded returns whether or not the inventory has been seeded with a full inventory
+func (i *Inventory) IsSeeded() bool {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.seeded
+}
+
+// TimestampMs returns the timestamp of the last applied delta, to be used when requesting the next delta
+func (i *Inventory) TimestampMs() int64 {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.timestampMs
+}
+
+// Pokemon returns the Pokémon in the inventory, not including eggs
+func (i *Inventory) Pokemon() []*protos.PokemonData {
+	return i.filterPokemon(false)
+}
+
+// Eggs returns the eggs in the inventory
+func (i *Inventory) Eggs() []*protos.PokemonData {
+	return i.filterPokemon(true)
+}
+
+func (i *Inventory) filterPokemon(eggs bool) []*protos.PokemonData {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	pokemon := make([]*protos.PokemonData, 0, len(i.pokemon))
+	for _, p := range i.pokemon {
+		if p.IsEgg == eggs {
+			pokemon = append(pokemon, p)
+		}
+	}
+	sort.Sort(pokemonByID(pokemon))
+	return pokemon
+}
+
+// Items returns the count of every item in the bag
+func (i *Inventory) Items() map[protos.ItemId]int32 {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	items := make(map[protos.ItemId]int32, len(i.items))
+	for id, count := range i.items {
+		items[id] = count
+	}
+	return items
+}
+
+// ItemCount returns the count of an item in the bag
+func (i *Inventory) ItemCount(item protos.ItemId) int32 {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.items[item]
+}
+
+func (i *Inventory) consumeItem(item protos.ItemId) int32 {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.items[item] > 0 {
+		i.items[item]--
+	}
+	return i.items[item]
+}
+
+// Candies returns the amount of candy for every Pokémon family
+func (i *Inventory) Candies() map[protos.PokemonFamilyId]int32 {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	candies := make(map[protos.PokemonFamilyId]int32, len(i.candies))
+	for family, count := range i.candies {
+		candies[family] = count
+	}
+	return candies
+}
+
+// Incubators returns the egg incubators of the player
+func (i *Inventory) Incubators() []*protos.EggIncubator {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.incubators
+}
+
+// AppliedItems returns the currently active items like incense and lucky eggs
+func (i *Inventory) AppliedItems() []*protos.AppliedItem {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.appliedItems
+}
+
+// PlayerStats returns the level, experience and other statistics of the player
+func (i *Inventory) PlayerStats() *protos.PlayerStats {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return i.stats
+}
+
+// Pokedex returns the Pokédex entries ordered by Pokémon number
+func (i *Inventory) Pokedex() []*protos.PokedexEntry {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	entries := make([]*protos.PokedexEntry, 0, len(i.pokedex))
+	for _, entry := range i.pokedex {
+		entries = append(entries, entry)
+	}
+	sort.Sort(pokedexByID(entries))
+	return entries
+}
+
+func (s *Session) updateInventory(lastTimestamp int64, inventory *protos.GetInventoryResponse) {
+	if !inventory.Success {
+		return
+	}
+	if lastTimestamp == 0 {
+		s.inventory.Seed(inventory.InventoryDelta)
+	} else {
+		s.inventory.Apply(inventory.InventoryDelta)
+	}
+}
+
+type pokemonByID []*protos.PokemonData
+
+func (a pokemonByID) Len() int           { return len(a) }
+func (a pokemonByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a pokemonByID) Less(i, j int) bool { return a[i].Id < a[j].Id }
+
+type pokedexByID []*protos.PokedexEntry
+
+func (a pokedexByID) Len() int           { return len(a) }
+func (a pokedexByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a pokedexByID) Less(i, j int) bool { return a[i].PokemonId < a[j].PokemonId }
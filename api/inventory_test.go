@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func inventoryPokemon(id uint64, pokemonID protos.PokemonId, egg bool) *protos.InventoryItem {
+	return &protos.InventoryItem{InventoryItemData: &protos.InventoryItemData{
+		PokemonData: &protos.PokemonData{Id: id, PokemonId: pokemonID, IsEgg: egg},
+	}}
+}
+
+func inventoryItem(id protos.ItemId, count int32) *protos.InventoryItem {
+	return &protos.InventoryItem{InventoryItemData: &protos.InventoryItemData{
+		Item: &protos.ItemData{ItemId: id, Count: count},
+	}}
+}
+
+func TestInventoryDeltas(t *testing.T) {
+	inventory := NewInventory()
+	if inventory.IsSeeded() {
+		t.Error("expected new inventory not to be seeded")
+	}
+
+	inventory.Seed(&protos.InventoryDelta{
+		NewTimestampMs: 1000,
+		InventoryItems: []*protos.InventoryItem{
+			inventoryPokemon(2, protos.PokemonId_PIKACHU, false),
+			inventoryPokemon(1, protos.PokemonId_PIDGEY, false),
+			inventoryPokemon(3, protos.PokemonId_MISSINGNO, true),
+			inventoryItem(protos.ItemId_ITEM_POKE_BALL, 20),
+			inventoryItem(protos.ItemId_ITEM_POTION, 5),
+			{InventoryItemData: &protos.InventoryItemData{Candy: &protos.Candy{FamilyId: protos.PokemonFamilyId_FAMILY_PIKACHU, Candy: 3}}},
+			{InventoryItemData: &protos.InventoryItemData{PlayerStats: &protos.PlayerStats{Level: 5}}},
+			{InventoryItemData: &protos.InventoryItemData{PokedexEntry: &protos.PokedexEntry{PokemonId: protos.PokemonId_PIKACHU, TimesCaptured: 1}}},
+			{InventoryItemData: &protos.InventoryItemData{PokedexEntry: &protos.PokedexEntry{PokemonId: protos.PokemonId_PIDGEY, TimesCaptured: 1}}},
+			{InventoryItemData: &protos.InventoryItemData{EggIncubators: &protos.EggIncubators{
+				EggIncubator: []*protos.EggIncubator{{Id: "incubator", ItemId: protos.ItemId_ITEM_INCUBATOR_BASIC_UNLIMITED}},
+			}}},
+		},
+	})
+
+	if !inventory.IsSeeded() || inventory.TimestampMs() != 1000 {
+		t.Errorf("unexpected seeded state %t at %d", inventory.IsSeeded(), inventory.TimestampMs())
+	}
+	pokemon := inventory.Pokemon()
+	if len(pokemon) != 2 || pokemon[0].Id != 1 || pokemon[1].Id != 2 {
+		t.Errorf("unexpected Pokémon %v", pokemon)
+	}
+	if eggs := inventory.Eggs(); len(eggs) != 1 || eggs[0].Id != 3 {
+		t.Errorf("unexpected eggs %v", eggs)
+	}
+	if pokedex := inventory.Pokedex(); len(pokedex) != 2 || pokedex[0].PokemonId != protos.PokemonId_PIDGEY {
+		t.Errorf("unexpected Pokédex %v", pokedex)
+	}
+	if len(inventory.Incubators()) != 1 || inventory.PlayerStats().Level != 5 {
+		t.Errorf("unexpected incubators %v or stats %v", inventory.Incubators(), inventory.PlayerStats())
+	}
+
+	inventory.Apply(&protos.InventoryDelta{
+		OriginalTimestampMs: 1000,
+		NewTimestampMs:      2000,
+		InventoryItems: []*protos.InventoryItem{
+			{DeletedItem: &protos.InventoryItem_DeletedItem{PokemonId: 1}},
+			inventoryItem(protos.ItemId_ITEM_POKE_BALL, 19),
+			{InventoryItemData: &protos.InventoryItemData{Candy: &protos.Candy{FamilyId: protos.PokemonFamilyId_FAMILY_PIKACHU, Candy: 6}}},
+		},
+	})
+
+	if inventory.TimestampMs() != 2000 {
+		t.Errorf("expected timestamp to advance, got %d", inventory.TimestampMs())
+	}
+	if pokemon := inventory.Pokemon(); len(pokemon) != 1 || pokemon[0].Id != 2 {
+		t.Errorf("expected released Pokémon to be removed, got %v", pokemon)
+	}
+	items := inventory.Items()
+	if items[protos.ItemId_ITEM_POKE_BALL] != 19 || items[protos.ItemId_ITEM_POTION] != 5 {
+		t.Errorf("unexpected items %v", items)
+	}
+	if candies := inventory.Candies(); candies[protos.PokemonFamilyId_FAMILY_PIKACHU] != 6 {
+		t.Errorf("unexpected candies %v", candies)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// ItemCount returns the amount of an item in the last known inventory
+func (s *Session) ItemCount(item protos.ItemId) int32 {
+	return s.inventory.ItemCount(item)
+}
+
+func (s *Session) checkItem(item protos.ItemId) error {
+	if !s.inventory.IsSeeded() {
+		return ErrUnknownInventory
+	}
+	if s.inventory.ItemCount(item) < 1 {
+		return ErrItemNotInInventory
+	}
+	return nil
+}
+
+func (s *Session) consumeItem(item protos.ItemId) int32 {
+	return s.inventory.consumeItem(item)
+}
+
+// UseItemPotion heals a Pokémon using a potion, returning the amount of potions left
+func (s *Session) UseItemPotion(ctx context.Context, item protos.ItemId, pokemonID uint64, proxyId int64) (*protos.UseItemPotionResponse, int32, error) {
+	if err := s.checkItem(item); err != nil {
+		return nil, s.ItemCount(item), err
+	}
+
+	response := &protos.UseItemPotionResponse{}
+	err := s.callSingle(ctx, protos.RequestType_USE_ITEM_POTION, &protos.UseItemPotionMessage{
+		ItemId:    item,
+		PokemonId: pokemonID,
+	}, response, proxyId)
+	if err != nil {
+		return nil, s.ItemCount(item), err
+	}
+	if response.Result != protos.UseItemPotionResponse_SUCCESS {
+		return nil, s.ItemCount(item), &ErrResult{response.Result}
+	}
+	return response, s.consumeItem(item), nil
+}
+
+// UseItemRevive revives a fainted Pokémon, returning the amount of revives left
+func (s *Session) UseItemRevive(ctx context.Context, item protos.ItemId, pokemonID uint64, proxyId int64) (*protos.UseItemReviveResponse, int32, error) {
+	if err := s.checkItem(item); err != nil {
+		return nil, s.ItemCount(item), err
+	}
+
+	response := &protos.UseItemReviveResponse{}
+	err := s.callSingle(ctx, protos.RequestType_USE_ITEM_REVIVE, &protos.UseItemReviveMessage{
+		ItemId:    item,
+		PokemonId: pokemonID,
+	}, response, proxyId)
+	if err != nil {
+		return nil, s.ItemCount(item), err
+	}
+	if response.Result != protos.UseItemReviveResponse_SUCCESS {
+		return nil, s.ItemCount(item), &ErrResult{response.Result}
+	}
+	return response, s.consumeItem(item), nil
+}
+
+// UseIncense activates an incense, returning the amount of that incense left
+func (s *Session) UseIncense(ctx context.Context, item protos.ItemId, proxyId int64) (*protos.UseIncenseResponse, int32, error) {
+	if err := s.checkItem(item); err != nil {
+		return nil, s.ItemCount(item), err
+	}
+
+	response := &protos.UseIncenseResponse{}
+	err := s.callSingle(ctx, protos.RequestType_USE_INCENSE, &protos.UseIncenseMessage{
+		IncenseType: item,
+	}, response, proxyId)
+	if err != nil {
+		return nil, s.ItemCount(item), err
+	}
+	if response.Result != protos.UseIncenseResponse_SUCCESS {
+		return nil, s.ItemCount(item), &ErrResult{response.Result}
+	}
+	return response, s.consumeItem(item), nil
+}
+
+// AddFortModifier puts a lure module on a fort, returning the amount of lure modules left
+func (s *Session) AddFortModifier(ctx context.Context, fort *protos.FortData, item protos.ItemId, proxyId int64) (*protos.AddFortModifierResponse, int32, error) {
+	if err := s.checkItem(item); err != nil {
+		return nil, s.ItemCount(item), err
+	}
+
+	location := s.currentLocation()
+	response := &protos.AddFortModifierResponse{}
+	err := s.callSingle(ctx, protos.RequestType_ADD_FORT_MODIFIER, &protos.AddFortModifierMessage{
+		ModifierType:    item,
+		FortId:          fort.Id,
+		PlayerLatitude:  location.Lat,
+		PlayerLongitude: location.Lon,
+	}, response, proxyId)
+	if err != nil {
+		return nil, s.ItemCount(item), err
+	}
+	if response.Result != protos.AddFortModifierResponse_SUCCESS {
+		return nil, s.ItemCount(item), &ErrResult{response.Result}
+	}
+	return response, s.consumeItem(item), nil
+}
+
+// UseItemXpBoost activates an experience boost like a lucky egg, returning the amount of that item left
+func (s *Session) UseItemXpBoost(ctx context.Context, item protos.ItemId, proxyId int64) (*protos.UseItemXpBoostResponse, int32, error) {
+	if err := s.checkItem(item); err != nil {
+		return nil, s.ItemCount(item), err
+	}
+
+	response := &protos.UseItemXpBoostResponse{}
+	err := s.callSingle(ctx, protos.RequestType_USE_ITEM_XP_BOOST, &protos.UseItemXpBoostMessage{
+		ItemId: item,
+	}, response, proxyId)
+	if err != nil {
+		return nil, s.ItemCount(item), err
+	}
+	if response.Result != protos.UseItemXpBoostResponse_SUCCESS {
+		return nil, s.ItemCount(item), &ErrResult{response.Result}
+	}
+	return response, s.consumeItem(item), nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestUseItemPotion(t *testing.T) {
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.GetInventoryResponse{
+			Success: true,
+			InventoryDelta: &protos.InventoryDelta{
+				InventoryItems: []*protos.InventoryItem{
+					{InventoryItemData: &protos.InventoryItemData{Item: &protos.ItemData{ItemId: protos.ItemId_ITEM_POTION, Count: 2}}},
+				},
+			},
+		}},
+		[]proto.Message{&protos.UseItemPotionResponse{Result: protos.UseItemPotionResponse_SUCCESS, Stamina: 20}},
+	)
+	defer server.Close()
+
+	ctx := context.Background()
+	session := server.session()
+	if _, _, err := session.UseItemPotion(ctx, protos.ItemId_ITEM_POTION, 1, -1); err != ErrUnknownInventory {
+		t.Errorf("expected unknown inventory, got %v", err)
+	}
+	if _, err := session.GetInventory(ctx, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := session.UseItemPotion(ctx, protos.ItemId_ITEM_SUPER_POTION, 1, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected missing item, got %v", err)
+	}
+
+	response, count, err := session.UseItemPotion(ctx, protos.ItemId_ITEM_POTION, 1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Stamina != 20 || count != 1 || session.ItemCount(protos.ItemId_ITEM_POTION) != 1 {
+		t.Errorf("unexpected result %v with %d potions left", response, count)
+	}
+}
+
+// newItemServer scripts a server that first returns an inventory with the items, and a session that has loaded it
+func newItemServer(t *testing.T, items []*protos.InventoryItem, script ...[]proto.Message) (*scriptedServer, *Session) {
+	inventory := []proto.Message{&protos.GetInventoryResponse{
+		Success:        true,
+		InventoryDelta: &protos.InventoryDelta{InventoryItems: items},
+	}}
+	server := newScriptedServer(t, append([][]proto.Message{inventory}, script...)...)
+	session := server.session()
+	if _, err := session.GetInventory(context.Background(), -1); err != nil {
+		t.Fatal(err)
+	}
+	return server, session
+}
+
+func TestUseItemRevive(t *testing.T) {
+	server, session := newItemServer(t, []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_REVIVE, 1)},
+		[]proto.Message{&protos.UseItemReviveResponse{Result: protos.UseItemReviveResponse_ERROR_DEPLOYED_TO_FORT}},
+		[]proto.Message{&protos.UseItemReviveResponse{Result: protos.UseItemReviveResponse_SUCCESS, Stamina: 30}},
+	)
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, _, err := session.UseItemRevive(ctx, protos.ItemId_ITEM_MAX_REVIVE, 7, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected missing item, got %v", err)
+	}
+	if response, count, err := session.UseItemRevive(ctx, protos.ItemId_ITEM_REVIVE, 7, -1); response != nil || count != 1 {
+		t.Errorf("expected a failed revive to keep the item, got %v with %d left: %v", response, count, err)
+	}
+	response, count, err := session.UseItemRevive(ctx, protos.ItemId_ITEM_REVIVE, 7, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &protos.UseItemReviveMessage{}
+	server.lastMessage(message)
+	if message.ItemId != protos.ItemId_ITEM_REVIVE || message.PokemonId != 7 {
+		t.Errorf("unexpected revive message %v", message)
+	}
+	if response.Stamina != 30 || count != 0 {
+		t.Errorf("unexpected result %v with %d revives left", response, count)
+	}
+	if _, _, err := session.UseItemRevive(ctx, protos.ItemId_ITEM_REVIVE, 7, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected the last revive to be used up, got %v", err)
+	}
+	if len(server.requests) != 3 {
+		t.Errorf("expected no requests for missing items, got %d requests", len(server.requests))
+	}
+}
+
+func TestUseIncense(t *testing.T) {
+	server, session := newItemServer(t, []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_INCENSE_ORDINARY, 1)},
+		[]proto.Message{&protos.UseIncenseResponse{Result: protos.UseIncenseResponse_SUCCESS}},
+	)
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, _, err := session.UseIncense(ctx, protos.ItemId_ITEM_INCENSE_SPICY, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected missing item, got %v", err)
+	}
+	_, count, err := session.UseIncense(ctx, protos.ItemId_ITEM_INCENSE_ORDINARY, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &protos.UseIncenseMessage{}
+	server.lastMessage(message)
+	if message.IncenseType != protos.ItemId_ITEM_INCENSE_ORDINARY || count != 0 {
+		t.Errorf("unexpected incense message %v with %d left", message, count)
+	}
+	if _, _, err := session.UseIncense(ctx, protos.ItemId_ITEM_INCENSE_ORDINARY, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected the incense to be used up, got %v", err)
+	}
+}
+
+func TestAddFortModifier(t *testing.T) {
+	server, session := newItemServer(t, []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_TROY_DISK, 2)},
+		[]proto.Message{&protos.AddFortModifierResponse{Result: protos.AddFortModifierResponse_TOO_FAR_AWAY}},
+		[]proto.Message{&protos.AddFortModifierResponse{Result: protos.AddFortModifierResponse_SUCCESS}},
+	)
+	defer server.Close()
+	ctx := context.Background()
+	fort := &protos.FortData{Id: "stop", Type: protos.FortType_CHECKPOINT}
+
+	if _, _, err := session.AddFortModifier(ctx, fort, protos.ItemId_ITEM_POTION, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected missing item, got %v", err)
+	}
+	response, count, err := session.AddFortModifier(ctx, fort, protos.ItemId_ITEM_TROY_DISK, -1)
+	if _, ok := err.(*ErrResult); !ok || response != nil || count != 2 {
+		t.Errorf("expected a result error keeping the lures, got %v with %d left: %v", response, count, err)
+	}
+	if _, count, err = session.AddFortModifier(ctx, fort, protos.ItemId_ITEM_TROY_DISK, -1); err != nil {
+		t.Fatal(err)
+	}
+	message := &protos.AddFortModifierMessage{}
+	server.lastMessage(message)
+	if message.FortId != "stop" || message.ModifierType != protos.ItemId_ITEM_TROY_DISK || message.PlayerLatitude != 59.33 || count != 1 {
+		t.Errorf("unexpected fort modifier message %v with %d left", message, count)
+	}
+}
+
+func TestUseItemXpBoost(t *testing.T) {
+	server, session := newItemServer(t, []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_LUCKY_EGG, 1)},
+		[]proto.Message{&protos.UseItemXpBoostResponse{Result: protos.UseItemXpBoostResponse_SUCCESS}},
+	)
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, _, err := session.UseItemXpBoost(ctx, protos.ItemId_ITEM_POTION, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected missing item, got %v", err)
+	}
+	_, count, err := session.UseItemXpBoost(ctx, protos.ItemId_ITEM_LUCKY_EGG, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &protos.UseItemXpBoostMessage{}
+	server.lastMessage(message)
+	if message.ItemId != protos.ItemId_ITEM_LUCKY_EGG || count != 0 {
+		t.Errorf("unexpected xp boost message %v with %d left", message, count)
+	}
+	if _, _, err := session.UseItemXpBoost(ctx, protos.ItemId_ITEM_LUCKY_EGG, -1); err != ErrItemNotInInventory {
+		t.Errorf("expected the lucky egg to be used up, got %v", err)
+	}
+}
@@ -26,6 +26,11 @@ type Location struct {
 	Accuracy float64
 }
 
+// IsValid returns whether or not the latitude and longitude are within range
+func (l *Location) IsValid() bool {
+	return !math.IsNaN(l.Lat) && !math.IsNaN(l.Lon) && l.Lat >= -90 && l.Lat <= 90 && l.Lon >= -180 && l.Lon <= 180
+}
+
 // GetCellIDs will return a slice of the closed neighbourhood cell ids for the current coordinates
 func (l *Location) GetCellIDs() CellIDs {
 	origin := s2.CellIDFromLatLng(s2.LatLngFromDegrees(l.Lat, l.Lon)).Parent(cellIDLevel)
@@ -40,22 +45,8 @@ func (l *Location) GetCellIDs() CellIDs {
 }
 
 // DistanceToFort returns distance between the location and a fort using the Haversine formula
-// Reference: https://gist.github.com/cdipaolo/d3f8db3848278b49db68
 func (l *Location) DistanceToFort(fort *protos.FortData) float64 {
-	// convert to radians
-	// must cast radius as float to multiply later
-	var la1, lo1, la2, lo2 float64
-	la1 = l.Lat * math.Pi / 180
-	lo1 = l.Lon * math.Pi / 180
-	la2 = fort.Latitude * math.Pi / 180
-	lo2 = fort.Longitude * math.Pi / 180
-
-	// calculate
-	dla := math.Sin(0.5 * (la2 - la1))
-	dlo := math.Sin(0.5 * (lo2 - lo1))
-	h := dla*dla + math.Cos(la1)*math.Cos(la2)*dlo*dlo
-
-	return 2 * earthRadiusInMeters * math.Asin(math.Sqrt(h))
+	return Distance(l, fort)
 }
 
 // GetBytes returns a byte slice of the location coordinates
@@ -1,9 +1,27 @@
 package api
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
+
+func TestLocationIsValid(t *testing.T) {
+	valid := []Location{{Lat: 90, Lon: 180}, {Lat: -90, Lon: -180}, {}}
+	for _, l := range valid {
+		if !l.IsValid() {
+			t.Errorf("expected %v to be valid", l)
+		}
+	}
+	invalid := []Location{{Lat: 91}, {Lon: -181}, {Lat: math.NaN()}, {Lon: math.NaN()}}
+	for _, l := range invalid {
+		if l.IsValid() {
+			t.Errorf("expected %v to be invalid", l)
+		}
+	}
+}
 
 func BenchmarkGetBytes(b *testing.B) {
-	l := Location{0.0, 0.0, 0.0}
+	l := Location{0.0, 0.0, 0.0, 0.0}
 	for n := 0; n < b.N; n++ {
 		l.GetBytes()
 	}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// MapCache keeps a persistent view of the map cells seen by a session
+// and remembers the timestamp of each cell so only changes need to be requested
+type MapCache struct {
+	mutex sync.RWMutex
+	cells map[uint64]*protos.MapCell
+}
+
+// NewMapCache constructs an empty map cache
+func NewMapCache() *MapCache {
+	return &MapCache{
+		cells: make(map[uint64]*protos.MapCell),
+	}
+}
+
+// SinceTimestamps returns the timestamp of each cell, or zero for cells that have not been seen yet
+func (m *MapCache) SinceTimestamps(cellIDs []uint64) []int64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	timestamps := make([]int64, len(cellIDs))
+	for i, cellID := range cellIDs {
+		if cell, ok := m.cells[cellID]; ok {
+			timestamps[i] = cell.CurrentTimestampMs
+		}
+	}
+	return timestamps
+}
+
+// Update merges the map cells of a response in to the cache
+func (m *MapCache) Update(mapObjects *protos.GetMapObjectsResponse) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, update := range mapObjects.GetMapCells() {
+		cell, ok := m.cells[update.S2CellId]
+		if !ok {
+			cell = &protos.MapCell{S2CellId: update.S2CellId}
+			m.cells[update.S2CellId] = cell
+		}
+		mergeMapCell(cell, update)
+	}
+}
+
+func mergeMapCell(cell *protos.MapCell, update *protos.MapCell) {
+	forts := make(map[string]int, len(cell.Forts))
+	for i, fort := range cell.Forts {
+		forts[fort.Id] = i
+	}
+	for _, fort := range update.Forts {
+		if i, ok := forts[fort.Id]; ok {
+			cell.Forts[i] = fort
+		} else {
+			forts[fort.Id] = len(cell.Forts)
+			cell.Forts = append(cell.Forts, fort)
+		}
+	}
+
+	if len(update.DeletedObjects) > 0 {
+		deleted := make(map[string]bool, len(update.DeletedObjects))
+		for _, id := range update.DeletedObjects {
+			deleted[id] = true
+		}
+		remaining := cell.Forts[:0]
+		for _, fort := range cell.Forts {
+			if !deleted[fort.Id] {
+				remaining = append(remaining, fort)
+			}
+		}
+		cell.Forts = remaining
+	}
+
+	cell.SpawnPoints = mergeSpawnPoints(cell.SpawnPoints, update.SpawnPoints)
+	cell.DecimatedSpawnPoints = mergeSpawnPoints(cell.DecimatedSpawnPoints, update.DecimatedSpawnPoints)
+
+	// Pokémon are always sent in full as they only live for a short while
+	cell.WildPokemons = update.WildPokemons
+	cell.CatchablePokemons = update.CatchablePokemons
+	cell.NearbyPokemons = update.NearbyPokemons
+
+	// A truncated list is incomplete, so keep the old timestamp to get the rest with the next request
+	if !update.IsTruncatedList && update.CurrentTimestampMs > cell.CurrentTimestampMs {
+		cell.CurrentTimestampMs = update.CurrentTimestampMs
+	}
+}
+
+func mergeSpawnPoints(spawnPoints []*protos.SpawnPoint, update []*protos.SpawnPoint) []*protos.SpawnPoint {
+	known := make(map[string]bool, len(spawnPoints))
+	for _, spawnPoint := range spawnPoints {
+		known[spawnPointKey(spawnPoint)] = true
+	}
+	for _, spawnPoint := range update {
+		key := spawnPointKey(spawnPoint)
+		if !known[key] {
+			known[key] = true
+			spawnPoints = append(spawnPoints, spawnPoint)
+		}
+	}
+	return spawnPoints
+}
+
+func spawnPointKey(spawnPoint *protos.SpawnPoint) string {
+	return fmt.Sprintf("%f,%f", spawnPoint.Latitude, spawnPoint.Longitude)
+}
+
+// Cell returns a copy of the merged state of a map cell
+func (m *MapCache) Cell(cellID uint64) (*protos.MapCell, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	cell, ok := m.cells[cellID]
+	if !ok {
+		return nil, false
+	}
+	return proto.Clone(cell).(*protos.MapCell), true
+}
+
+// MapObjects returns a copy of the merged state of the requested cells, as if the full cells were downloaded
+func (m *MapCache) MapObjects(cellIDs []uint64) *protos.GetMapObjectsResponse {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	mapObjects := &protos.GetMapObjectsResponse{Status: protos.MapObjectsStatus_SUCCESS}
+	for _, cellID := range cellIDs {
+		if cell, ok := m.cells[cellID]; ok {
+			mapObjects.MapCells = append(mapObjects.MapCells, proto.Clone(cell).(*protos.MapCell))
+		}
+	}
+	return mapObjects
+}
+
+// Merge updates the cache with a response and returns the merged state of the cells in it,
+// keeping the timestamp, truncation and deleted objects the response reported for each cell
+func (m *MapCache) Merge(mapObjects *protos.GetMapObjectsResponse) *protos.GetMapObjectsResponse {
+	m.Update(mapObjects)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	merged := &protos.GetMapObjectsResponse{Status: mapObjects.Status}
+	for _, update := range mapObjects.GetMapCells() {
+		cell := proto.Clone(m.cells[update.S2CellId]).(*protos.MapCell)
+		cell.CurrentTimestampMs = update.CurrentTimestampMs
+		cell.IsTruncatedList = update.IsTruncatedList
+		cell.DeletedObjects = update.DeletedObjects
+		merged.MapCells = append(merged.MapCells, cell)
+	}
+	return merged
+}
+
+// Reset forgets all cells, causing the next requests to download full cells
+func (m *MapCache) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cells = make(map[uint64]*protos.MapCell)
+}
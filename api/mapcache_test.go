@@ -0,0 +1,59 @@
+package api
+
+import (
+	"testing"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestMapCacheMerge(t *testing.T) {
+	cache := NewMapCache()
+	cellIDs := []uint64{1, 2}
+
+	if timestamps := cache.SinceTimestamps(cellIDs); timestamps[0] != 0 || timestamps[1] != 0 {
+		t.Errorf("expected unseen cells to have no timestamp, got %v", timestamps)
+	}
+
+	cache.Update(&protos.GetMapObjectsResponse{MapCells: []*protos.MapCell{
+		{
+			S2CellId:           1,
+			CurrentTimestampMs: 1000,
+			Forts:              []*protos.FortData{{Id: "a"}, {Id: "b"}},
+			SpawnPoints:        []*protos.SpawnPoint{{Latitude: 1, Longitude: 1}},
+			WildPokemons:       []*protos.WildPokemon{{EncounterId: 1}},
+		},
+		{S2CellId: 2, CurrentTimestampMs: 1000, IsTruncatedList: true},
+	}})
+
+	if timestamps := cache.SinceTimestamps(cellIDs); timestamps[0] != 1000 || timestamps[1] != 0 {
+		t.Errorf("unexpected timestamps %v", timestamps)
+	}
+
+	cache.Update(&protos.GetMapObjectsResponse{MapCells: []*protos.MapCell{
+		{
+			S2CellId:           1,
+			CurrentTimestampMs: 2000,
+			Forts:              []*protos.FortData{{Id: "b", OwnedByTeam: protos.TeamColor_RED}, {Id: "c"}},
+			DeletedObjects:     []string{"a"},
+			SpawnPoints:        []*protos.SpawnPoint{{Latitude: 1, Longitude: 1}, {Latitude: 2, Longitude: 2}},
+			WildPokemons:       []*protos.WildPokemon{{EncounterId: 2}},
+		},
+	}})
+
+	cell, ok := cache.Cell(1)
+	if !ok || cell.CurrentTimestampMs != 2000 {
+		t.Fatalf("unexpected cell %v", cell)
+	}
+	if len(cell.Forts) != 2 || cell.Forts[0].Id != "b" || cell.Forts[0].OwnedByTeam != protos.TeamColor_RED || cell.Forts[1].Id != "c" {
+		t.Errorf("unexpected forts %v", cell.Forts)
+	}
+	if len(cell.SpawnPoints) != 2 {
+		t.Errorf("expected spawn points to be merged, got %v", cell.SpawnPoints)
+	}
+	if len(cell.WildPokemons) != 1 || cell.WildPokemons[0].EncounterId != 2 {
+		t.Errorf("expected Pokémon to be replaced, got %v", cell.WildPokemons)
+	}
+	if mapObjects := cache.MapObjects(cellIDs); len(mapObjects.MapCells) != 2 {
+		t.Errorf("expected both cells in map objects, got %d", len(mapObjects.MapCells))
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// GetPlayerProfile returns the public profile of a player, or the current player if the name is empty
+func (s *Session) GetPlayerProfile(ctx context.Context, playerName string, proxyId int64) (*protos.GetPlayerProfileResponse, error) {
+	response := &protos.GetPlayerProfileResponse{}
+	err := s.callSingle(ctx, protos.RequestType_GET_PLAYER_PROFILE, &protos.GetPlayerProfileMessage{
+		PlayerName: playerName,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.GetPlayerProfileResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+	return response, nil
+}
+
+// LevelUpRewards claims the rewards for reaching a player level
+func (s *Session) LevelUpRewards(ctx context.Context, level int32, proxyId int64) (*protos.LevelUpRewardsResponse, error) {
+	response := &protos.LevelUpRewardsResponse{}
+	err := s.callSingle(ctx, protos.RequestType_LEVEL_UP_REWARDS, &protos.LevelUpRewardsMessage{
+		Level: level,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.LevelUpRewardsResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+	return response, nil
+}
+
+// SetAvatar sets the appearance of the player's avatar
+func (s *Session) SetAvatar(ctx context.Context, avatar *protos.PlayerAvatar, proxyId int64) (*protos.SetAvatarResponse, error) {
+	response := &protos.SetAvatarResponse{}
+	err := s.callSingle(ctx, protos.RequestType_SET_AVATAR, &protos.SetAvatarMessage{
+		PlayerAvatar: avatar,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != protos.SetAvatarResponse_SUCCESS {
+		return nil, &ErrResult{response.Status}
+	}
+	return response, nil
+}
+
+// SetPlayerTeam chooses the team the player belongs to
+func (s *Session) SetPlayerTeam(ctx context.Context, team protos.TeamColor, proxyId int64) (*protos.SetPlayerTeamResponse, error) {
+	response := &protos.SetPlayerTeamResponse{}
+	err := s.callSingle(ctx, protos.RequestType_SET_PLAYER_TEAM, &protos.SetPlayerTeamMessage{
+		Team: team,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != protos.SetPlayerTeamResponse_SUCCESS {
+		return nil, &ErrResult{response.Status}
+	}
+	return response, nil
+}
+
+// SetContactSettings updates whether the player receives marketing emails and push notifications
+func (s *Session) SetContactSettings(ctx context.Context, settings *protos.ContactSettings, proxyId int64) (*protos.SetContactSettingsResponse, error) {
+	response := &protos.SetContactSettingsResponse{}
+	err := s.callSingle(ctx, protos.RequestType_SET_CONTACT_SETTINGS, &protos.SetContactSettingsMessage{
+		ContactSettings: settings,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != protos.SetContactSettingsResponse_SUCCESS {
+		return nil, &ErrResult{response.Status}
+	}
+	return response, nil
+}
+
+// SetBuddyPokemon chooses the Pokémon that walks with the player
+func (s *Session) SetBuddyPokemon(ctx context.Context, pokemonID uint64, proxyId int64) (*protos.SetBuddyPokemonResponse, error) {
+	response := &protos.SetBuddyPokemonResponse{}
+	err := s.callSingle(ctx, protos.RequestType_SET_BUDDY_POKEMON, &protos.SetBuddyPokemonMessage{
+		PokemonId: pokemonID,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.SetBuddyPokemonResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+	return response, nil
+}
+
+// GetBuddyWalked returns the candies earned by walking with the buddy Pokémon
+func (s *Session) GetBuddyWalked(ctx context.Context, proxyId int64) (*protos.GetBuddyWalkedResponse, error) {
+	response := &protos.GetBuddyWalkedResponse{}
+	err := s.callSingle(ctx, protos.RequestType_GET_BUDDY_WALKED, &protos.GetBuddyWalkedMessage{}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// CheckCodenameAvailable checks whether a player name can be claimed
+//
+// An unavailable codename is not treated as an error, inspect the status and IsAssignable of the response instead
+func (s *Session) CheckCodenameAvailable(ctx context.Context, codename string, proxyId int64) (*protos.CheckCodenameAvailableResponse, error) {
+	response := &protos.CheckCodenameAvailableResponse{}
+	err := s.callSingle(ctx, protos.RequestType_CHECK_CODENAME_AVAILABLE, &protos.CheckCodenameAvailableMessage{
+		Codename: codename,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ClaimCodename claims a player name for the account
+func (s *Session) ClaimCodename(ctx context.Context, codename string, proxyId int64) (*protos.ClaimCodenameResponse, error) {
+	response := &protos.ClaimCodenameResponse{}
+	err := s.callSingle(ctx, protos.RequestType_CLAIM_CODENAME, &protos.ClaimCodenameMessage{
+		Codename: codename,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != protos.ClaimCodenameResponse_SUCCESS {
+		return nil, &ErrResult{response.Status}
+	}
+	return response, nil
+}
+
+// MarkTutorialComplete marks the tutorial steps as completed for the player
+func (s *Session) MarkTutorialComplete(ctx context.Context, tutorials []protos.TutorialState, sendMarketingEmails, sendPushNotifications bool, proxyId int64) (*protos.MarkTutorialCompleteResponse, error) {
+	response := &protos.MarkTutorialCompleteResponse{}
+	err := s.callSingle(ctx, protos.RequestType_MARK_TUTORIAL_COMPLETE, &protos.MarkTutorialCompleteMessage{
+		TutorialsCompleted:    tutorials,
+		SendMarketingEmails:   sendMarketingEmails,
+		SendPushNotifications: sendPushNotifications,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		return nil, ErrRequest
+	}
+	return response, nil
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestPlayerRequests(t *testing.T) {
+	ctx := context.Background()
+	avatar := &protos.PlayerAvatar{Skin: 1, Hair: 2}
+	settings := &protos.ContactSettings{SendPushNotifications: true}
+
+	cases := []struct {
+		name        string
+		requestType protos.RequestType
+		request     proto.Message
+		response    proto.Message
+		call        func(s *Session) (proto.Message, error)
+	}{
+		{
+			"GetPlayerProfile", protos.RequestType_GET_PLAYER_PROFILE,
+			&protos.GetPlayerProfileMessage{PlayerName: "ash"},
+			&protos.GetPlayerProfileResponse{Result: protos.GetPlayerProfileResponse_SUCCESS, StartTime: 1000},
+			func(s *Session) (proto.Message, error) { return s.GetPlayerProfile(ctx, "ash", -1) },
+		},
+		{
+			"LevelUpRewards", protos.RequestType_LEVEL_UP_REWARDS,
+			&protos.LevelUpRewardsMessage{Level: 5},
+			&protos.LevelUpRewardsResponse{Result: protos.LevelUpRewardsResponse_SUCCESS, ItemsUnlocked: []protos.ItemId{protos.ItemId_ITEM_POTION}},
+			func(s *Session) (proto.Message, error) { return s.LevelUpRewards(ctx, 5, -1) },
+		},
+		{
+			"SetAvatar", protos.RequestType_SET_AVATAR,
+			&protos.SetAvatarMessage{PlayerAvatar: avatar},
+			&protos.SetAvatarResponse{Status: protos.SetAvatarResponse_SUCCESS},
+			func(s *Session) (proto.Message, error) { return s.SetAvatar(ctx, avatar, -1) },
+		},
+		{
+			"SetPlayerTeam", protos.RequestType_SET_PLAYER_TEAM,
+			&protos.SetPlayerTeamMessage{Team: protos.TeamColor_YELLOW},
+			&protos.SetPlayerTeamResponse{Status: protos.SetPlayerTeamResponse_SUCCESS},
+			func(s *Session) (proto.Message, error) { return s.SetPlayerTeam(ctx, protos.TeamColor_YELLOW, -1) },
+		},
+		{
+			"SetContactSettings", protos.RequestType_SET_CONTACT_SETTINGS,
+			&protos.SetContactSettingsMessage{ContactSettings: settings},
+			&protos.SetContactSettingsResponse{Status: protos.SetContactSettingsResponse_SUCCESS},
+			func(s *Session) (proto.Message, error) { return s.SetContactSettings(ctx, settings, -1) },
+		},
+		{
+			"SetBuddyPokemon", protos.RequestType_SET_BUDDY_POKEMON,
+			&protos.SetBuddyPokemonMessage{PokemonId: 42},
+			&protos.SetBuddyPokemonResponse{Result: protos.SetBuddyPokemonResponse_SUCCESS, UpdatedBuddy: &protos.BuddyPokemon{Id: 42}},
+			func(s *Session) (proto.Message, error) { return s.SetBuddyPokemon(ctx, 42, -1) },
+		},
+		{
+			"GetBuddyWalked", protos.RequestType_GET_BUDDY_WALKED,
+			&protos.GetBuddyWalkedMessage{},
+			&protos.GetBuddyWalkedResponse{Success: true, CandyEarnedCount: 1},
+			func(s *Session) (proto.Message, error) { return s.GetBuddyWalked(ctx, -1) },
+		},
+		{
+			"CheckCodenameAvailable", protos.RequestType_CHECK_CODENAME_AVAILABLE,
+			&protos.CheckCodenameAvailableMessage{Codename: "ash"},
+			&protos.CheckCodenameAvailableResponse{Codename: "ash", Status: protos.CheckCodenameAvailableResponse_CODENAME_NOT_AVAILABLE},
+			func(s *Session) (proto.Message, error) { return s.CheckCodenameAvailable(ctx, "ash", -1) },
+		},
+		{
+			"ClaimCodename", protos.RequestType_CLAIM_CODENAME,
+			&protos.ClaimCodenameMessage{Codename: "ash"},
+			&protos.ClaimCodenameResponse{Codename: "ash", Status: protos.ClaimCodenameResponse_SUCCESS},
+			func(s *Session) (proto.Message, error) { return s.ClaimCodename(ctx, "ash", -1) },
+		},
+		{
+			"MarkTutorialComplete", protos.RequestType_MARK_TUTORIAL_COMPLETE,
+			&protos.MarkTutorialCompleteMessage{TutorialsCompleted: []protos.TutorialState{protos.TutorialState_LEGAL_SCREEN}, SendPushNotifications: true},
+			&protos.MarkTutorialCompleteResponse{Success: true},
+			func(s *Session) (proto.Message, error) {
+				return s.MarkTutorialComplete(ctx, []protos.TutorialState{protos.TutorialState_LEGAL_SCREEN}, false, true, -1)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		server := newScriptedServer(t, []proto.Message{c.response})
+		response, err := c.call(server.session())
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			server.Close()
+			continue
+		}
+
+		request := server.requests[0].Requests[0]
+		if request.RequestType != c.requestType {
+			t.Errorf("%s: expected request type %s, got %s", c.name, c.requestType, request.RequestType)
+		}
+		message := proto.Clone(c.request)
+		message.Reset()
+		server.lastMessage(message)
+		if !proto.Equal(message, c.request) {
+			t.Errorf("%s: expected request message %v, got %v", c.name, c.request, message)
+		}
+		if !proto.Equal(response, c.response) {
+			t.Errorf("%s: expected response %v, got %v", c.name, c.response, response)
+		}
+		server.Close()
+	}
+}
+
+func TestPlayerRequestFailures(t *testing.T) {
+	ctx := context.Background()
+	server := newScriptedServer(t,
+		[]proto.Message{&protos.SetPlayerTeamResponse{Status: protos.SetPlayerTeamResponse_TEAM_ALREADY_SET}},
+		[]proto.Message{&protos.MarkTutorialCompleteResponse{Success: false}},
+	)
+	defer server.Close()
+	session := server.session()
+
+	response, err := session.SetPlayerTeam(ctx, protos.TeamColor_RED, -1)
+	if result, ok := err.(*ErrResult); !ok || result.Result != protos.SetPlayerTeamResponse_TEAM_ALREADY_SET {
+		t.Errorf("expected the result to be reported, got %v", err)
+	}
+	if response != nil {
+		t.Errorf("expected no response on failure, got %v", response)
+	}
+
+	tutorial, err := session.MarkTutorialComplete(ctx, nil, false, false, -1)
+	if err != ErrRequest || tutorial != nil {
+		t.Errorf("expected the request to fail without a response, got %v %v", tutorial, err)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+type testProvider struct{}
+
+func (p *testProvider) Login(ctx context.Context) (string, error) { return "token", nil }
+func (p *testProvider) GetProviderString() string                 { return "ptc" }
+func (p *testProvider) GetAccessToken() string                    { return "token" }
+
+type testCrypto struct{}
+
+func (c *testCrypto) CreateIV(timestampSinceStart uint32) []byte   { return nil }
+func (c *testCrypto) Encrypt(in []byte, iv []byte) ([]byte, error) { return in, nil }
+func (c *testCrypto) Enabled() bool                                { return false }
+
+// scriptedServer replies to each RPC call with the next return messages in the script
+type scriptedServer struct {
+	*httptest.Server
+	t        *testing.T
+	script   [][]proto.Message
+	requests []*protos.RequestEnvelope
+	// apiURL is sent in every response, as the first call of a session expects
+	apiURL string
+}
+
+func newScriptedServer(t *testing.T, script ...[]proto.Message) *scriptedServer {
+	s := &scriptedServer{t: t, script: script}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *scriptedServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	requestEnvelope := &protos.RequestEnvelope{}
+	if err := proto.Unmarshal(body, requestEnvelope); err != nil {
+		s.t.Errorf("could not decode request: %s", err)
+	}
+	s.requests = append(s.requests, requestEnvelope)
+
+	if len(s.script) == 0 {
+		s.t.Errorf("unexpected request %v", requestEnvelope.Requests)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	returns := s.script[0]
+	s.script = s.script[1:]
+
+	responseEnvelope := &protos.ResponseEnvelope{StatusCode: protos.ResponseEnvelope_OK, ApiUrl: s.apiURL}
+	for _, message := range returns {
+		b, _ := proto.Marshal(message)
+		responseEnvelope.Returns = append(responseEnvelope.Returns, b)
+	}
+	b, _ := proto.Marshal(responseEnvelope)
+	w.Write(b)
+}
+
+func (s *scriptedServer) session() *Session {
+	session := NewSession(&testProvider{}, &Location{Lat: 59.33, Lon: 18.06, Accuracy: 3}, &VoidFeed{}, &testCrypto{}, false)
+	session.url = s.URL
+	return session
+}
+
+func (s *scriptedServer) lastMessage(pb proto.Message) {
+	requests := s.requests[len(s.requests)-1].Requests
+	if err := proto.Unmarshal(requests[0].RequestMessage, pb); err != nil {
+		s.t.Fatalf("could not decode request message: %s", err)
+	}
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
@@ -11,8 +13,6 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 
-	"errors"
-
 	"github.com/femot/pgoapi-go/auth"
 	protos "github.com/pogodevorg/POGOProtos-go"
 	mr "math/rand"
@@ -23,9 +23,8 @@ const downloadSettingsHash = "05daf51635c82611d1aac95c0b051d3ec088a930"
 
 // Session is used to communicate with the Pokémon Go API
 type Session struct {
-	feed     Feed
+	feed     EntryFeed
 	crypto   Crypto
-	location *Location
 	rpc      *RPC
 	url      string
 	debug    bool
@@ -36,6 +35,14 @@ type Session struct {
 	started   time.Time
 	provider  auth.Provider
 	hash      []byte
+	inventory *Inventory
+	mapCache  *MapCache
+	coverage  Coverage
+	accountID string
+
+	// location is guarded as it can be moved while requests are made, like by a walker
+	locationMutex sync.Mutex
+	location      *Location
 }
 
 func generateRequests() []*protos.Request {
@@ -54,12 +61,28 @@ func NewSession(provider auth.Provider, location *Location, feed Feed, crypto Cr
 		provider:  provider,
 		debug:     debug,
 		debugger:  &jsonpb.Marshaler{Indent: "\t"},
-		feed:      feed,
+		feed:      entryFeed(feed),
 		crypto:    crypto,
 		started:   time.Now(),
 		hasTicket: false,
 		hash:      make([]byte, 32),
+		inventory: NewInventory(),
+		mapCache:  NewMapCache(),
+		coverage:  &DefaultCoverage{},
+		accountID: accountID(provider),
+	}
+}
+
+// accountID identifies the account of a provider that knows its username, without revealing the username
+// to whoever reads the feeds
+func accountID(provider auth.Provider) string {
+	if p, ok := provider.(interface {
+		GetUsername() string
+	}); ok {
+		sum := sha256.Sum256([]byte(provider.GetProviderString() + ":" + p.GetUsername()))
+		return fmt.Sprintf("%s:%x", provider.GetProviderString(), sum[:8])
 	}
+	return ""
 }
 
 // IsExpired checks the expiration timestamp of the sessions AuthTicket
@@ -77,6 +100,22 @@ func (s *Session) SetTimeout(d time.Duration) {
 	s.rpc.http.Timeout = d
 }
 
+// SetEntryFeed replaces the feed with one receiving every response with the details of its call
+func (s *Session) SetEntryFeed(f EntryFeed) {
+	s.feed = f
+}
+
+// SetAccountID sets the account id feed entries are labelled with,
+// replacing the default made from a hash of the provider and username
+func (s *Session) SetAccountID(id string) {
+	s.accountID = id
+}
+
+// SetCoverage sets which map cells around the location are requested when announcing
+func (s *Session) SetCoverage(c Coverage) {
+	s.coverage = c
+}
+
 func (s *Session) setTicket(ticket *protos.AuthTicket) {
 	s.hasTicket = true
 	s.ticket = ticket
@@ -105,6 +144,18 @@ func (s *Session) debugProtoMessage(label string, pb proto.Message) {
 
 // Call queries the Pokémon Go API through RPC protobuf
 func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId int64) (*protos.ResponseEnvelope, error) {
+	responseEnvelope, _, err := s.call(ctx, requests, proxyId)
+	return responseEnvelope, err
+}
+
+// call queries the API and describes the call in a feed entry without request type and message
+func (s *Session) call(ctx context.Context, requests []*protos.Request, proxyId int64) (*protos.ResponseEnvelope, *FeedEntry, error) {
+	location := s.currentLocation()
+	entry := &FeedEntry{
+		Timestamp: time.Now(),
+		AccountID: s.accountID,
+		Location:  *location,
+	}
 
 	requestEnvelope := &protos.RequestEnvelope{
 		RequestId:  uint64(8145806132888207460),
@@ -112,10 +163,10 @@ func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId
 
 		MsSinceLastLocationfix: int64(989),
 
-		Longitude: s.location.Lon,
-		Latitude:  s.location.Lat,
+		Longitude: location.Lon,
+		Latitude:  location.Lat,
 
-		Accuracy: int32(s.location.Accuracy),
+		Accuracy: int32(location.Accuracy),
 
 		Requests: requests,
 	}
@@ -140,19 +191,19 @@ func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId
 		for idx, request := range requests {
 			hash, err := generateRequestHash(s.ticket, request)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			requestHash[idx] = hash
 		}
 
-		locationHash1, err := generateLocation1(s.ticket, s.location)
+		locationHash1, err := generateLocation1(s.ticket, location)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		locationHash2, err := generateLocation2(s.location)
+		locationHash2, err := generateLocation2(location)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		lf := make([]*protos.Signature_LocationFix, 1)
@@ -160,8 +211,8 @@ func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId
 			Provider:           "network",
 			TimestampSnapshot:  t - getTimestamp(s.started),
 			Altitude:           mr.Float32(),
-			Latitude:           float32(s.location.Lat),
-			Longitude:          float32(s.location.Lon),
+			Latitude:           float32(location.Lat),
+			Longitude:          float32(location.Lon),
 			Speed:              float32(mr.Intn(15)),
 			Course:             float32(mr.Intn(360)),
 			HorizontalAccuracy: mr.Float32(),
@@ -213,20 +264,20 @@ func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId
 
 		signatureProto, err := proto.Marshal(signature)
 		if err != nil {
-			return nil, ErrFormatting
+			return nil, nil, ErrFormatting
 		}
 
 		iv := s.crypto.CreateIV(uint32(t - getTimestamp(s.started)))
 		encryptedSignature, err := s.crypto.Encrypt(signatureProto, iv)
 		if err != nil {
-			return nil, ErrFormatting
+			return nil, nil, ErrFormatting
 		}
 
 		requestMessage, err := proto.Marshal(&protos.SendEncryptedSignatureRequest{
 			EncryptedSignature: encryptedSignature,
 		})
 		if err != nil {
-			return nil, ErrFormatting
+			return nil, nil, ErrFormatting
 		}
 
 		requestEnvelope.PlatformRequests = []*protos.RequestEnvelope_PlatformRequest{
@@ -245,12 +296,75 @@ func (s *Session) Call(ctx context.Context, requests []*protos.Request, proxyId
 
 	s.debugProtoMessage("response envelope", responseEnvelope)
 
-	return responseEnvelope, err
+	entry.Latency = time.Since(entry.Timestamp)
+	if responseEnvelope != nil {
+		entry.StatusCode = responseEnvelope.StatusCode
+	}
+
+	return responseEnvelope, entry, err
+}
+
+func (s *Session) push(call *FeedEntry, requestType protos.RequestType, message proto.Message) {
+	entry := *call
+	entry.RequestType = requestType
+	entry.Message = message
+	s.feed.PushEntry(&entry)
+}
+
+// callSingle performs a single request and decodes the first return into the response message
+func (s *Session) callSingle(ctx context.Context, requestType protos.RequestType, message proto.Message, response proto.Message, proxyId int64) error {
+	request := &protos.Request{RequestType: requestType}
+	if message != nil {
+		requestMessage, err := proto.Marshal(message)
+		if err != nil {
+			return ErrFormatting
+		}
+		request.RequestMessage = requestMessage
+	}
+
+	responseEnvelope, entry, err := s.call(ctx, []*protos.Request{request}, proxyId)
+	if err != nil {
+		return err
+	}
+	if len(responseEnvelope.Returns) < 1 {
+		return ErrEmptyResponse
+	}
+
+	err = proto.Unmarshal(responseEnvelope.Returns[0], response)
+	if err != nil {
+		return &ErrResponse{err}
+	}
+	s.push(entry, requestType, response)
+	s.debugProtoMessage("response return[0]", response)
+
+	return GetErrorFromStatus(responseEnvelope.StatusCode)
+}
+
+// Inventory returns the player inventory as known from the last inventory responses
+func (s *Session) Inventory() *Inventory {
+	return s.inventory
+}
+
+// MapCache returns the merged view of all map cells seen by the session
+func (s *Session) MapCache() *MapCache {
+	return s.mapCache
 }
 
 // MoveTo sets your current location
 func (s *Session) MoveTo(location *Location) {
-	s.location = location
+	moved := *location
+
+	s.locationMutex.Lock()
+	defer s.locationMutex.Unlock()
+
+	s.location = &moved
+}
+
+func (s *Session) currentLocation() *Location {
+	s.locationMutex.Lock()
+	defer s.locationMutex.Unlock()
+
+	return s.location
 }
 
 // Init initializes the client by performing full authentication
@@ -291,14 +405,33 @@ func (s *Session) Init(ctx context.Context, proxyId int64) error {
 	ticket := response.GetAuthTicket()
 	s.setTicket(ticket)
 
+	// Seed the inventory with the full inventory, so the first announce only asks for the changes
+	if len(response.Returns) > 2 {
+		inventory := &protos.GetInventoryResponse{}
+		if err := proto.Unmarshal(response.Returns[2], inventory); err != nil {
+			return &ErrResponse{err}
+		}
+		s.updateInventory(0, inventory)
+	}
+
 	return nil
 }
 
-// Announce publishes the player's presence and returns the map environment
+// Announce publishes the player's presence and returns the map environment,
+// with the forts of cells seen before merged from the map cache
+//
+// The map objects, the hatched eggs and the inventory changes are all pushed to the feed,
+// as a *protos.GetMapObjectsResponse, *protos.GetHatchedEggsResponse and *protos.GetInventoryResponse.
 func (s *Session) Announce(ctx context.Context, proxyId int64) (mapObjects *protos.GetMapObjectsResponse, err error) {
 
-	cellIDs := s.location.GetCellIDs()
-	lastTimestamp := time.Now().Unix() * 1000
+	location := s.currentLocation()
+	cellIDs := s.coverage.CellIDs(location)
+
+	// Ask for the changes since the last known inventory, or the full inventory if there is none yet
+	var lastTimestamp int64
+	if s.inventory.IsSeeded() {
+		lastTimestamp = s.inventory.TimestampMs()
+	}
 
 	settingsMessage, _ := proto.Marshal(&protos.DownloadSettingsMessage{
 		Hash: downloadSettingsHash,
@@ -309,13 +442,13 @@ func (s *Session) Announce(ctx context.Context, proxyId int64) (mapObjects *prot
 		CellId: cellIDs,
 
 		// Timestamps in milliseconds corresponding to each route cell id
-		SinceTimestampMs: make([]int64, len(cellIDs)),
+		SinceTimestampMs: s.mapCache.SinceTimestamps(cellIDs),
 
 		// Current longitide and latitude
-		Longitude: s.location.Lon,
-		Latitude:  s.location.Lat,
+		Longitude: location.Lon,
+		Latitude:  location.Lat,
 	})
-	// Request the inventory with a message containing the current time
+	// Request the inventory with a message containing the last inventory timestamp
 	getInventoryMessage, _ := proto.Marshal(&protos.GetInventoryMessage{
 		LastTimestampMs: lastTimestamp,
 	})
@@ -329,7 +462,7 @@ func (s *Session) Announce(ctx context.Context, proxyId int64) (mapObjects *prot
 		{RequestType: protos.RequestType_GET_BUDDY_WALKED},
 	}
 
-	response, err := s.Call(ctx, requests, proxyId)
+	response, entry, err := s.call(ctx, requests, proxyId)
 	if err != nil {
 		if err == ErrProxyDead {
 			return mapObjects, err
@@ -338,16 +471,35 @@ func (s *Session) Announce(ctx context.Context, proxyId int64) (mapObjects *prot
 	}
 
 	mapObjects = &protos.GetMapObjectsResponse{}
-	if len(response.Returns) < 5 {
-		return nil, errors.New("Empty response")
+	if len(response.Returns) < 6 {
+		return nil, ErrEmptyResponse
 	}
 	err = proto.Unmarshal(response.Returns[0], mapObjects)
 	if err != nil {
 		return nil, &ErrResponse{err}
 	}
-	s.feed.Push(mapObjects)
+	// Only changed forts are sent for cells seen before, so pass on the merged cells instead
+	mapObjects = s.mapCache.Merge(mapObjects)
+	s.push(entry, protos.RequestType_GET_MAP_OBJECTS, mapObjects)
 	s.debugProtoMessage("response return[0]", mapObjects)
 
+	hatchedEggs := &protos.GetHatchedEggsResponse{}
+	err = proto.Unmarshal(response.Returns[1], hatchedEggs)
+	if err != nil {
+		return nil, &ErrResponse{err}
+	}
+	s.push(entry, protos.RequestType_GET_HATCHED_EGGS, hatchedEggs)
+	s.debugProtoMessage("response return[1]", hatchedEggs)
+
+	inventory := &protos.GetInventoryResponse{}
+	err = proto.Unmarshal(response.Returns[2], inventory)
+	if err != nil {
+		return nil, &ErrResponse{err}
+	}
+	s.updateInventory(lastTimestamp, inventory)
+	s.push(entry, protos.RequestType_GET_INVENTORY, inventory)
+	s.debugProtoMessage("response return[2]", inventory)
+
 	challenge := protos.CheckChallengeResponse{}
 	err = proto.Unmarshal(response.Returns[5], &challenge)
 	if challenge.ShowChallenge {
@@ -360,7 +512,7 @@ func (s *Session) Announce(ctx context.Context, proxyId int64) (mapObjects *prot
 // GetPlayer returns the current player profile
 func (s *Session) GetPlayer(ctx context.Context, proxyId int64) (*protos.GetPlayerResponse, error) {
 	requests := []*protos.Request{{RequestType: protos.RequestType_GET_PLAYER}}
-	response, err := s.Call(ctx, requests, proxyId)
+	response, entry, err := s.call(ctx, requests, proxyId)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +522,7 @@ func (s *Session) GetPlayer(ctx context.Context, proxyId int64) (*protos.GetPlay
 	if err != nil {
 		return nil, &ErrResponse{err}
 	}
-	s.feed.Push(player)
+	s.push(entry, protos.RequestType_GET_PLAYER, player)
 	s.debugProtoMessage("response return[0]", player)
 
 	return player, GetErrorFromStatus(response.StatusCode)
@@ -384,7 +536,7 @@ func (s *Session) GetPlayerMap(ctx context.Context, proxyId int64) (*protos.GetM
 // GetInventory returns the player items
 func (s *Session) GetInventory(ctx context.Context, proxyId int64) (*protos.GetInventoryResponse, error) {
 	requests := []*protos.Request{{RequestType: protos.RequestType_GET_INVENTORY}}
-	response, err := s.Call(ctx, requests, proxyId)
+	response, entry, err := s.call(ctx, requests, proxyId)
 	if err != nil {
 		return nil, err
 	}
@@ -393,7 +545,8 @@ func (s *Session) GetInventory(ctx context.Context, proxyId int64) (*protos.GetI
 	if err != nil {
 		return nil, &ErrResponse{err}
 	}
-	s.feed.Push(inventory)
+	s.updateInventory(0, inventory)
+	s.push(entry, protos.RequestType_GET_INVENTORY, inventory)
 	s.debugProtoMessage("response return[0]", inventory)
 
 	return inventory, GetErrorFromStatus(response.StatusCode)
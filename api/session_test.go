@@ -0,0 +1,186 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// announceReturns are the returns of an announce with the map objects and inventory
+func announceReturns(mapObjects *protos.GetMapObjectsResponse, inventory *protos.GetInventoryResponse) []proto.Message {
+	return []proto.Message{
+		mapObjects,
+		&protos.GetHatchedEggsResponse{Success: true},
+		inventory,
+		&protos.CheckAwardedBadgesResponse{Success: true},
+		&protos.DownloadSettingsResponse{},
+		&protos.CheckChallengeResponse{},
+		&protos.GetBuddyWalkedResponse{},
+	}
+}
+
+func TestAnnounceMergesMapCells(t *testing.T) {
+	session := NewSession(&testProvider{}, &Location{Lat: 59.33, Lon: 18.06, Accuracy: 3}, &VoidFeed{}, &testCrypto{}, false)
+	cellID := session.coverage.CellIDs(session.location)[0]
+
+	server := newScriptedServer(t,
+		announceReturns(&protos.GetMapObjectsResponse{
+			Status: protos.MapObjectsStatus_SUCCESS,
+			MapCells: []*protos.MapCell{{
+				S2CellId:           cellID,
+				CurrentTimestampMs: 1000,
+				Forts:              []*protos.FortData{{Id: "a"}, {Id: "b", GymPoints: 1}},
+			}},
+		}, &protos.GetInventoryResponse{Success: true}),
+		announceReturns(&protos.GetMapObjectsResponse{
+			Status: protos.MapObjectsStatus_SUCCESS,
+			MapCells: []*protos.MapCell{{
+				S2CellId:           cellID,
+				CurrentTimestampMs: 2000,
+				Forts:              []*protos.FortData{{Id: "b", GymPoints: 2}},
+			}},
+		}, &protos.GetInventoryResponse{Success: true}),
+	)
+	defer server.Close()
+	session.url = server.URL
+
+	ctx := context.Background()
+	if _, err := session.Announce(ctx, -1); err != nil {
+		t.Fatal(err)
+	}
+	mapObjects, err := session.Announce(ctx, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := &protos.GetMapObjectsMessage{}
+	server.lastMessage(message)
+	if message.CellId[0] != cellID || message.SinceTimestampMs[0] != 1000 {
+		t.Errorf("expected the cell to be requested since 1000, got %v", message.SinceTimestampMs)
+	}
+	for i, timestamp := range message.SinceTimestampMs[1:] {
+		if timestamp != 0 {
+			t.Errorf("expected unseen cell %d to have no timestamp, got %d", message.CellId[i+1], timestamp)
+		}
+	}
+
+	cells := mapObjects.GetMapCells()
+	if len(cells) != 1 || len(cells[0].Forts) != 2 || cells[0].CurrentTimestampMs != 2000 {
+		t.Fatalf("expected the unchanged fort to be merged in, got %v", cells)
+	}
+	if cells[0].Forts[1].GymPoints != 2 {
+		t.Errorf("expected the changed fort to be updated, got %v", cells[0].Forts[1])
+	}
+}
+
+func TestAnnounceInventoryDeltas(t *testing.T) {
+	mapObjects := &protos.GetMapObjectsResponse{Status: protos.MapObjectsStatus_SUCCESS}
+	server := newScriptedServer(t,
+		announceReturns(mapObjects, &protos.GetInventoryResponse{
+			Success: true,
+			InventoryDelta: &protos.InventoryDelta{
+				NewTimestampMs: 1000,
+				InventoryItems: []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_POTION, 5)},
+			},
+		}),
+		announceReturns(mapObjects, &protos.GetInventoryResponse{
+			Success: true,
+			InventoryDelta: &protos.InventoryDelta{
+				OriginalTimestampMs: 1000,
+				NewTimestampMs:      2000,
+				InventoryItems:      []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_POTION, 3)},
+			},
+		}),
+		announceReturns(mapObjects, &protos.GetInventoryResponse{Success: true})[:5],
+	)
+	defer server.Close()
+	session := server.session()
+	feed := &entryRecorder{}
+	session.SetEntryFeed(feed)
+
+	ctx := context.Background()
+	lastTimestamps := make([]int64, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := session.Announce(ctx, -1); err != nil {
+			t.Fatal(err)
+		}
+		message := &protos.GetInventoryMessage{}
+		requests := server.requests[len(server.requests)-1].Requests
+		if err := proto.Unmarshal(requests[2].RequestMessage, message); err != nil {
+			t.Fatal(err)
+		}
+		lastTimestamps = append(lastTimestamps, message.LastTimestampMs)
+	}
+
+	if lastTimestamps[0] != 0 || lastTimestamps[1] != 1000 {
+		t.Errorf("expected the full inventory and then the changes since 1000, got %v", lastTimestamps)
+	}
+	if session.Inventory().TimestampMs() != 2000 || session.ItemCount(protos.ItemId_ITEM_POTION) != 3 {
+		t.Errorf("expected the delta to be applied, got %d potions at %d", session.ItemCount(protos.ItemId_ITEM_POTION), session.Inventory().TimestampMs())
+	}
+
+	inventories := make([]*protos.GetInventoryResponse, 0)
+	for _, entry := range feed.entries {
+		if inventory, ok := entry.Message.(*protos.GetInventoryResponse); ok {
+			inventories = append(inventories, inventory)
+		}
+	}
+	if len(inventories) != 2 || inventories[1].InventoryDelta.OriginalTimestampMs != 1000 {
+		t.Errorf("expected the inventory delta to be pushed, got %v", inventories)
+	}
+
+	// A response without all returns is rejected instead of read past its end
+	if _, err := session.Announce(ctx, -1); err != ErrEmptyResponse {
+		t.Errorf("expected a short response to be rejected, got %v", err)
+	}
+}
+
+func TestInitSeedsInventory(t *testing.T) {
+	server := newScriptedServer(t, []proto.Message{
+		&protos.GetPlayerResponse{Success: true},
+		&protos.GetHatchedEggsResponse{Success: true},
+		&protos.GetInventoryResponse{
+			Success: true,
+			InventoryDelta: &protos.InventoryDelta{
+				NewTimestampMs: 1000,
+				InventoryItems: []*protos.InventoryItem{inventoryItem(protos.ItemId_ITEM_POTION, 5)},
+			},
+		},
+		&protos.CheckAwardedBadgesResponse{Success: true},
+		&protos.DownloadSettingsResponse{},
+	})
+	defer server.Close()
+	server.apiURL = "pgorelease.nianticlabs.com/plfe/1"
+	session := server.session()
+
+	if err := session.Init(context.Background(), -1); err != nil {
+		t.Fatal(err)
+	}
+	if !session.Inventory().IsSeeded() || session.Inventory().TimestampMs() != 1000 || session.ItemCount(protos.ItemId_ITEM_POTION) != 5 {
+		t.Errorf("expected the inventory to be seeded by init, got %d potions at %d", session.ItemCount(protos.ItemId_ITEM_POTION), session.Inventory().TimestampMs())
+	}
+}
+
+type namedProvider struct {
+	testProvider
+}
+
+func (p *namedProvider) GetUsername() string { return "ash" }
+
+func TestAccountID(t *testing.T) {
+	session := NewSession(&namedProvider{}, &Location{}, &VoidFeed{}, &testCrypto{}, false)
+	id := session.accountID
+	if !strings.HasPrefix(id, "ptc:") || strings.Contains(id, "ash") {
+		t.Errorf("expected an account id not revealing the username, got %q", id)
+	}
+	if other := NewSession(&namedProvider{}, &Location{}, &VoidFeed{}, &testCrypto{}, false); other.accountID != id {
+		t.Errorf("expected the account id to be stable, got %q and %q", id, other.accountID)
+	}
+	if session := NewSession(&testProvider{}, &Location{}, &VoidFeed{}, &testCrypto{}, false); session.accountID != "" {
+		t.Errorf("expected no account id without a username, got %q", session.accountID)
+	}
+}
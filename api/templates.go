@@ -0,0 +1,36 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// DownloadRemoteConfigVersion returns the timestamps of the latest item templates and asset digest
+func (s *Session) DownloadRemoteConfigVersion(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.DownloadRemoteConfigVersionResponse, error) {
+	response := &protos.DownloadRemoteConfigVersionResponse{}
+	err := s.callSingle(ctx, protos.RequestType_DOWNLOAD_REMOTE_CONFIG_VERSION, &protos.DownloadRemoteConfigVersionMessage{
+		Platform:   platform,
+		AppVersion: appVersion,
+	}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if response.Result != protos.DownloadRemoteConfigVersionResponse_SUCCESS {
+		return nil, &ErrResult{response.Result}
+	}
+	return response, nil
+}
+
+// DownloadItemTemplates returns the game master item templates
+func (s *Session) DownloadItemTemplates(ctx context.Context, proxyId int64) (*protos.DownloadItemTemplatesResponse, error) {
+	response := &protos.DownloadItemTemplatesResponse{}
+	err := s.callSingle(ctx, protos.RequestType_DOWNLOAD_ITEM_TEMPLATES, &protos.DownloadItemTemplatesMessage{}, response, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		return nil, ErrRequest
+	}
+	return response, nil
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// arrivalTolerance is the distance in metres within which a waypoint counts as reached
+const arrivalTolerance = 0.01
+
+// Clock is a common interface for telling and waiting for time, so that movement can be simulated
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is a clock following the system time
+type RealClock struct{}
+
+// Now returns the current system time
+func (c *RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to pass
+func (c *RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// StepFunc is called every time the walker has moved the session, returning an error stops the walk
+type StepFunc func(ctx context.Context, location *Location) error
+
+// AnnounceStep returns a step function that announces the player's presence at every step
+func AnnounceStep(session *Session, proxyId int64) StepFunc {
+	return func(ctx context.Context, location *Location) error {
+		_, err := session.Announce(ctx, proxyId)
+		return err
+	}
+}
+
+// Walker moves a session along a route at a given speed in steps
+type Walker struct {
+	session  *Session
+	route    []*Location
+	speed    float64
+	interval time.Duration
+	clock    Clock
+	step     StepFunc
+
+	mutex   sync.Mutex
+	resumed chan struct{}
+}
+
+// NewWalker constructs a walker following the route at a speed in metres per second,
+// moving the session every interval
+func NewWalker(session *Session, route []*Location, speed float64, interval time.Duration) *Walker {
+	// Copy the waypoints so the route can not be changed under a walk
+	copied := make([]*Location, len(route))
+	for i, location := range route {
+		waypoint := *location
+		copied[i] = &waypoint
+	}
+
+	return &Walker{
+		session:  session,
+		route:    copied,
+		speed:    speed,
+		interval: interval,
+		clock:    &RealClock{},
+	}
+}
+
+// SetClock sets the clock used to wait between steps and to measure how far the walker got
+func (w *Walker) SetClock(c Clock) {
+	w.clock = c
+}
+
+// OnStep sets the function called after every step
+func (w *Walker) OnStep(f StepFunc) {
+	w.step = f
+}
+
+// Pause stops the walker after its current step until it is resumed
+func (w *Walker) Pause() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.resumed == nil {
+		w.resumed = make(chan struct{})
+	}
+}
+
+// Resume continues a paused walk
+func (w *Walker) Resume() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.resumed != nil {
+		close(w.resumed)
+		w.resumed = nil
+	}
+}
+
+// IsPaused returns whether or not the walker is paused
+func (w *Walker) IsPaused() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.resumed != nil
+}
+
+// wait waits for the next step and returns how long the walker has walked since the given time,
+// leaving out the time it was paused
+func (w *Walker) wait(ctx context.Context, since time.Time) (time.Duration, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-w.clock.After(w.interval):
+	}
+	walked := w.clock.Now().Sub(since)
+
+	w.mutex.Lock()
+	resumed := w.resumed
+	w.mutex.Unlock()
+	if resumed == nil {
+		return walked, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-resumed:
+		return walked, nil
+	}
+}
+
+func (w *Walker) moveTo(ctx context.Context, location *Location) error {
+	w.session.MoveTo(location)
+	if w.step == nil {
+		return nil
+	}
+	return w.step(ctx, location)
+}
+
+// Walk moves the session from the start of the route to the end, returning early when the context is done
+func (w *Walker) Walk(ctx context.Context) error {
+	if len(w.route) == 0 || w.speed <= 0 || w.interval <= 0 {
+		return ErrInvalidRoute
+	}
+
+	current := w.route[0]
+	last := w.clock.Now()
+	err := w.moveTo(ctx, current)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	for next < len(w.route) {
+		// The walker keeps going while a step is announced, so it covers the time since the last step
+		walked, err := w.wait(ctx, last)
+		if err != nil {
+			return err
+		}
+		last = w.clock.Now()
+
+		// Carry the distance left over after reaching a waypoint on to the next segment
+		remaining := w.speed * walked.Seconds()
+		for remaining > 0 && next < len(w.route) {
+			d := current.DistanceTo(w.route[next])
+			if d <= remaining+arrivalTolerance {
+				current = w.route[next]
+				remaining -= d
+				next++
+			} else {
+				current = current.Interpolate(w.route[next], remaining/d)
+				remaining = 0
+			}
+		}
+
+		err = w.moveTo(ctx, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// testClock lets time pass instantly, telling every wait on a channel
+type testClock struct {
+	now   time.Time
+	waits chan time.Duration
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	if c.waits != nil {
+		c.waits <- d
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestWalk(t *testing.T) {
+	start := &Location{Lat: 0, Lon: 0}
+	route := []*Location{start, start.Destination(90, 100), start.Destination(90, 100).Destination(0, 50)}
+	session := NewSession(&testProvider{}, start, &VoidFeed{}, &testCrypto{}, false)
+
+	walker := NewWalker(session, route, 10, 3*time.Second)
+	clock := &testClock{}
+	walker.SetClock(clock)
+
+	steps := []*Location{}
+	walker.OnStep(func(ctx context.Context, location *Location) error {
+		if *session.currentLocation() != *location {
+			t.Error("expected the session to be moved before the step")
+		}
+		steps = append(steps, location)
+		return nil
+	})
+
+	if err := walker.Walk(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 150 metres at 30 metres per step is the start and five steps
+	if len(steps) != 6 {
+		t.Fatalf("expected 6 steps, got %d", len(steps))
+	}
+	if clock.now.Sub(time.Time{}) != 15*time.Second {
+		t.Errorf("expected the walk to take 15 seconds, took %s", clock.now.Sub(time.Time{}))
+	}
+	for i := 1; i < 4; i++ {
+		if d := steps[i].DistanceTo(start); math.Abs(d-float64(i)*30) > 0.01 {
+			t.Errorf("step %d: expected to be %d metres from the start, got %.2f", i, i*30, d)
+		}
+	}
+	if d := steps[4].DistanceTo(route[1]); math.Abs(d-20) > 0.01 {
+		t.Errorf("expected the fifth step to continue 20 metres past the corner, got %.2f", d)
+	}
+	if *steps[len(steps)-1] != *route[2] {
+		t.Errorf("expected to end at the last waypoint, got %v", steps[len(steps)-1])
+	}
+}
+
+func TestWalkCopiesRoute(t *testing.T) {
+	start := &Location{Lat: 0, Lon: 0}
+	end := start.Destination(90, 100)
+	session := NewSession(&testProvider{}, start, &VoidFeed{}, &testCrypto{}, false)
+
+	walker := NewWalker(session, []*Location{start, end}, 10, 20*time.Second)
+	walker.SetClock(&testClock{})
+	end.Lat = 10
+
+	if err := walker.Walk(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := session.currentLocation().DistanceTo(start); math.Abs(d-100) > 0.01 {
+		t.Errorf("expected to walk the route as given, ended %.2f metres from the start", d)
+	}
+}
+
+func TestWalkCountsStepTime(t *testing.T) {
+	start := &Location{Lat: 0, Lon: 0}
+	route := []*Location{start, start.Destination(90, 100)}
+	session := NewSession(&testProvider{}, start, &VoidFeed{}, &testCrypto{}, false)
+
+	walker := NewWalker(session, route, 10, 3*time.Second)
+	clock := &testClock{}
+	walker.SetClock(clock)
+
+	// Every step takes a second, which the walker keeps walking through
+	steps := []*Location{}
+	walker.OnStep(func(ctx context.Context, location *Location) error {
+		clock.now = clock.now.Add(time.Second)
+		steps = append(steps, location)
+		return nil
+	})
+
+	if err := walker.Walk(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := steps[1].DistanceTo(start); math.Abs(d-40) > 0.01 {
+		t.Errorf("expected the first step to be 40 metres from the start, got %.2f", d)
+	}
+	if len(steps) != 4 {
+		t.Errorf("expected 4 steps, got %d", len(steps))
+	}
+}
+
+func TestWalkWhileAnnouncing(t *testing.T) {
+	mapObjects := &protos.GetMapObjectsResponse{Status: protos.MapObjectsStatus_SUCCESS}
+	inventory := &protos.GetInventoryResponse{Success: true}
+	server := newScriptedServer(t,
+		announceReturns(mapObjects, inventory),
+		announceReturns(mapObjects, inventory),
+		announceReturns(mapObjects, inventory),
+	)
+	defer server.Close()
+
+	session := server.session()
+	start := session.currentLocation()
+	walker := NewWalker(session, []*Location{start, start.Destination(0, 1000)}, 10, time.Second)
+	walker.SetClock(&testClock{})
+
+	done := make(chan error)
+	go func() {
+		done <- walker.Walk(context.Background())
+	}()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := session.Announce(ctx, -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkPauseAndCancel(t *testing.T) {
+	start := &Location{Lat: 0, Lon: 0}
+	route := []*Location{start, start.Destination(0, 1000)}
+	session := NewSession(&testProvider{}, start, &VoidFeed{}, &testCrypto{}, false)
+
+	walker := NewWalker(session, route, 10, time.Second)
+	clock := &testClock{waits: make(chan time.Duration)}
+	walker.SetClock(clock)
+
+	steps := make(chan *Location)
+	walker.OnStep(func(ctx context.Context, location *Location) error {
+		select {
+		case steps <- location:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- walker.Walk(ctx)
+	}()
+
+	<-steps
+	<-clock.waits
+	<-steps
+	walker.Pause()
+	if !walker.IsPaused() {
+		t.Error("expected the walker to be paused")
+	}
+
+	// The wait in progress completes, but no step is taken while paused
+	<-clock.waits
+	select {
+	case <-steps:
+		t.Error("expected no steps while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	walker.Resume()
+	if walker.IsPaused() {
+		t.Error("expected the walker to be resumed")
+	}
+	location := <-steps
+	if d := location.DistanceTo(start); math.Abs(d-20) > 0.01 {
+		t.Errorf("expected to continue 20 metres from the start, got %.2f", d)
+	}
+
+	cancel()
+	for {
+		select {
+		case <-clock.waits:
+		case err := <-done:
+			if err != context.Canceled {
+				t.Errorf("expected the walk to be canceled, got %v", err)
+			}
+			return
+		}
+	}
+}
+
+func TestWalkInvalidRoute(t *testing.T) {
+	session := NewSession(&testProvider{}, &Location{}, &VoidFeed{}, &testCrypto{}, false)
+	if err := NewWalker(session, nil, 10, time.Second).Walk(context.Background()); err != ErrInvalidRoute {
+		t.Errorf("expected an empty route to be invalid, got %v", err)
+	}
+	if err := NewWalker(session, []*Location{{}}, 0, time.Second).Walk(context.Background()); err != ErrInvalidRoute {
+		t.Errorf("expected a zero speed to be invalid, got %v", err)
+	}
+}
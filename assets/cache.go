@@ -0,0 +1,198 @@
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+const (
+	digestFile = "digest.pb"
+	// bundlesDir keeps the bundles apart from the digest and temporary files, whatever their names
+	bundlesDir = "bundles"
+)
+
+// ErrNoDownloadURL happens when the remote service did not provide a download URL for a changed asset
+var ErrNoDownloadURL = errors.New("assets: No download URL for asset")
+
+// Cache stores asset bundles in a directory along with the digest entries they were downloaded for
+type Cache struct {
+	dir     string
+	fetcher Fetcher
+	digest  map[string]*protos.AssetDigestEntry
+}
+
+// NewCache opens the asset cache in a directory, creating it if needed
+func NewCache(dir string, fetcher Fetcher) (*Cache, error) {
+	err := os.MkdirAll(filepath.Join(dir, bundlesDir), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		dir:     dir,
+		fetcher: fetcher,
+		digest:  make(map[string]*protos.AssetDigestEntry),
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, digestFile))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored := &protos.GetAssetDigestResponse{}
+	err = proto.Unmarshal(b, stored)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range stored.Digest {
+		c.digest[entry.AssetId] = entry
+	}
+
+	return c, nil
+}
+
+// Entry returns the digest entry of a stored asset
+func (c *Cache) Entry(assetID string) (*protos.AssetDigestEntry, bool) {
+	entry, ok := c.digest[assetID]
+	return entry, ok
+}
+
+// Path returns the location of the file an asset is stored in
+func (c *Cache) Path(entry *protos.AssetDigestEntry) string {
+	name := entry.BundleName
+	if name == "" {
+		name = entry.AssetId
+	}
+	return filepath.Join(c.dir, bundlesDir, url.QueryEscape(name))
+}
+
+// Changed returns the entries of the digest that are missing from the cache or have a different checksum
+func (c *Cache) Changed(digest []*protos.AssetDigestEntry) []*protos.AssetDigestEntry {
+	changed := make([]*protos.AssetDigestEntry, 0)
+	for _, entry := range digest {
+		stored, ok := c.digest[entry.AssetId]
+		if !ok || stored.Checksum != entry.Checksum || stored.Version != entry.Version {
+			changed = append(changed, entry)
+		}
+	}
+	return changed
+}
+
+// Download fetches the assets from their download URLs into the cache directory
+func (c *Cache) Download(ctx context.Context, entries []*protos.AssetDigestEntry, urls []*protos.DownloadUrlEntry) error {
+	locations := make(map[string]*protos.DownloadUrlEntry)
+	for _, u := range urls {
+		locations[u.AssetId] = u
+	}
+
+	for _, entry := range entries {
+		location, ok := locations[entry.AssetId]
+		if !ok {
+			c.save()
+			return ErrNoDownloadURL
+		}
+		err := c.fetch(ctx, entry, location)
+		if err != nil {
+			// Keep track of the assets that were downloaded before the failure
+			c.save()
+			return err
+		}
+		c.digest[entry.AssetId] = entry
+	}
+
+	return c.save()
+}
+
+func (c *Cache) fetch(ctx context.Context, entry *protos.AssetDigestEntry, location *protos.DownloadUrlEntry) error {
+	body, err := c.fetcher.Fetch(ctx, location.Url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	path := c.Path(entry)
+	tmp, err := ioutil.TempFile(c.dir, "download")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, body)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+	if location.Size > 0 && size != int64(location.Size) {
+		return fmt.Errorf("assets: Downloaded %d bytes of %s, expected %d", size, entry.AssetId, location.Size)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *Cache) save() error {
+	stored := &protos.GetAssetDigestResponse{}
+	for _, entry := range c.digest {
+		stored.Digest = append(stored.Digest, entry)
+	}
+	sort.Sort(byAssetID(stored.Digest))
+
+	b, err := proto.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, digestFile)
+	err = ioutil.WriteFile(path+".tmp", b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Client is the part of api.Session used to retrieve the asset digest and download URLs
+type Client interface {
+	GetAssetDigest(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.GetAssetDigestResponse, error)
+	GetDownloadURLs(ctx context.Context, assetIDs []string, proxyId int64) (*protos.GetDownloadUrlsResponse, error)
+}
+
+// Update retrieves the asset digest through the session and downloads all assets that changed
+func (c *Cache) Update(ctx context.Context, session Client, platform protos.Platform, appVersion uint32, proxyId int64) ([]*protos.AssetDigestEntry, error) {
+	digest, err := session.GetAssetDigest(ctx, platform, appVersion, proxyId)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := c.Changed(digest.Digest)
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	assetIDs := make([]string, len(changed))
+	for i, entry := range changed {
+		assetIDs[i] = entry.AssetId
+	}
+	urls, err := session.GetDownloadURLs(ctx, assetIDs, proxyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, c.Download(ctx, changed, urls.DownloadUrls)
+}
+
+type byAssetID []*protos.AssetDigestEntry
+
+func (a byAssetID) Len() int           { return len(a) }
+func (a byAssetID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byAssetID) Less(i, j int) bool { return a[i].AssetId < a[j].AssetId }
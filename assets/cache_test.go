@@ -0,0 +1,151 @@
+package assets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func TestCacheDownload(t *testing.T) {
+	bundles := map[string]string{
+		"/pm0001": "bulbasaur",
+		"/i18n":   "translations",
+	}
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := bundles[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer cdn.Close()
+
+	dir, err := ioutil.TempDir("", "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewCache(dir, &HTTPFetcher{Client: http.DefaultClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := []*protos.AssetDigestEntry{
+		{AssetId: "pm0001/1", BundleName: "pm0001", Checksum: 1},
+		{AssetId: "i18n/1", BundleName: "i18n", Checksum: 2},
+	}
+	urls := []*protos.DownloadUrlEntry{
+		{AssetId: "pm0001/1", Url: cdn.URL + "/pm0001", Size: 9},
+		{AssetId: "i18n/1", Url: cdn.URL + "/i18n", Size: 12},
+	}
+
+	changed := cache.Changed(digest)
+	if len(changed) != 2 {
+		t.Fatalf("expected all assets to be new, got %d", len(changed))
+	}
+	if err := cache.Download(context.Background(), changed, urls); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(cache.Path(digest[0]))
+	if err != nil || string(b) != "bulbasaur" {
+		t.Errorf("unexpected asset content %q: %v", b, err)
+	}
+
+	// Reopen the cache to make sure the digest was persisted
+	cache, err = NewCache(dir, &HTTPFetcher{Client: http.DefaultClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest[1] = &protos.AssetDigestEntry{AssetId: "i18n/1", BundleName: "i18n", Checksum: 3}
+	changed = cache.Changed(digest)
+	if len(changed) != 1 || changed[0].AssetId != "i18n/1" {
+		t.Errorf("expected only the translations to have changed, got %v", changed)
+	}
+
+	urls[1].Size = 100
+	if err := cache.Download(context.Background(), changed, urls); err == nil {
+		t.Error("expected size mismatch to fail the download")
+	}
+	if entry, _ := cache.Entry("i18n/1"); entry.Checksum != 2 {
+		t.Errorf("expected failed download to keep the old entry, got %v", entry)
+	}
+}
+
+// testClient serves a digest and the download URLs of a CDN, counting the requests for URLs
+type testClient struct {
+	cdn     string
+	digest  []*protos.AssetDigestEntry
+	lookups int
+}
+
+func (c *testClient) GetAssetDigest(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.GetAssetDigestResponse, error) {
+	return &protos.GetAssetDigestResponse{Result: protos.GetAssetDigestResponse_SUCCESS, Digest: c.digest}, nil
+}
+
+func (c *testClient) GetDownloadURLs(ctx context.Context, assetIDs []string, proxyId int64) (*protos.GetDownloadUrlsResponse, error) {
+	c.lookups++
+	response := &protos.GetDownloadUrlsResponse{}
+	for _, id := range assetIDs {
+		response.DownloadUrls = append(response.DownloadUrls, &protos.DownloadUrlEntry{AssetId: id, Url: c.cdn + "/" + id})
+	}
+	return response, nil
+}
+
+func TestCacheUpdate(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer cdn.Close()
+
+	dir, err := ioutil.TempDir("", "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewCache(dir, &HTTPFetcher{Client: http.DefaultClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bundle named like the digest file does not overwrite it
+	client := &testClient{cdn: cdn.URL, digest: []*protos.AssetDigestEntry{
+		{AssetId: "pm0001", BundleName: "pm0001", Checksum: 1},
+		{AssetId: "digest.pb", BundleName: digestFile, Checksum: 2},
+	}}
+	ctx := context.Background()
+
+	changed, err := cache.Update(ctx, client, protos.Platform_IOS, 4500, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || client.lookups != 1 {
+		t.Fatalf("expected both assets to be downloaded with one lookup, got %d after %d", len(changed), client.lookups)
+	}
+	if b, err := ioutil.ReadFile(cache.Path(client.digest[1])); err != nil || string(b) != "/digest.pb" {
+		t.Errorf("unexpected asset content %q: %v", b, err)
+	}
+
+	cache, err = NewCache(dir, &HTTPFetcher{Client: http.DefaultClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Entry("pm0001"); !ok {
+		t.Errorf("expected the digest to survive a bundle with its name in %s", filepath.Join(dir, digestFile))
+	}
+	changed, err = cache.Update(ctx, client, protos.Platform_IOS, 4500, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 || client.lookups != 1 {
+		t.Errorf("expected nothing to change without a lookup, got %d after %d", len(changed), client.lookups)
+	}
+}
@@ -0,0 +1,34 @@
+// Package assets keeps a local copy of the asset bundles used by the Pokémon Go client
+package assets
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Fetcher is a common interface for retrieving the content of an asset download URL
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// HTTPFetcher retrieves assets using a HTTP client
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// Fetch performs a GET request for the URL and returns the response body
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	response, err := ctxhttp.Get(ctx, f.Client, url)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("assets: Status code was %d, expected 200", response.StatusCode)
+	}
+	return response.Body, nil
+}
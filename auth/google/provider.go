@@ -51,6 +51,11 @@ func (p *Provider) GetAccessToken() string {
 	return p.ticket
 }
 
+// GetUsername will return the username the provider logs in with
+func (p *Provider) GetUsername() string {
+	return p.username
+}
+
 // Login retrieves an access token from the Pokémon Trainer's Club
 func (p *Provider) Login(ctx context.Context) (string, error) {
 	sig, err := signature(p.username, p.password)
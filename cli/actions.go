@@ -4,11 +4,13 @@ import (
 	"golang.org/x/net/context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/urfave/cli"
 
-	"github.com/pogodevorg/pgoapi-go/api"
-	"github.com/pogodevorg/pgoapi-go/auth"
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/auth"
+	"github.com/femot/pgoapi-go/export"
 )
 
 func fail(e error) *cli.ExitError {
@@ -37,11 +39,11 @@ func getAccessToken(ctx context.Context, session *api.Session, provider auth.Pro
 }
 
 func getPlayer(ctx context.Context, session *api.Session, provider auth.Provider) error {
-	err := session.Init(ctx)
+	err := session.Init(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
-	profile, err := session.GetPlayer(ctx)
+	profile, err := session.GetPlayer(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
@@ -55,11 +57,11 @@ func getPlayer(ctx context.Context, session *api.Session, provider auth.Provider
 }
 
 func getInventory(ctx context.Context, session *api.Session, provider auth.Provider) error {
-	err := session.Init(ctx)
+	err := session.Init(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
-	inventory, err := session.GetInventory(ctx)
+	inventory, err := session.GetInventory(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
@@ -72,16 +74,34 @@ func getInventory(ctx context.Context, session *api.Session, provider auth.Provi
 	return nil
 }
 
-func getMap(ctx context.Context, session *api.Session, provider auth.Provider) error {
-	err := session.Init(ctx)
+// formatJSON prints the map objects as they are received instead of as features
+const formatJSON = export.Format("json")
+
+func (w *wrapper) getMap(ctx context.Context, session *api.Session, provider auth.Provider) error {
+	format := export.Format(strings.ToLower(w.format))
+	if format != formatJSON {
+		var err error
+		format, err = export.ParseFormat(w.format)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	err := session.Init(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
-	mapObjects, err := session.GetPlayerMap(ctx)
+	mapObjects, err := session.GetPlayerMap(ctx, -1)
 	if isFailure(err) {
 		return fail(err)
 	}
-	out, err := json.Marshal(mapObjects)
+
+	var out []byte
+	if format == formatJSON {
+		out, err = json.Marshal(mapObjects)
+	} else {
+		out, err = export.Encode(format, export.Features(mapObjects, w.location().GetCellIDs()))
+	}
 	if isFailure(err) {
 		return fail(err)
 	}
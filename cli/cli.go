@@ -1,7 +1,9 @@
 package cli
 
 import (
-	"github.com/pogodevorg/pgoapi-go/api"
+	"time"
+
+	"github.com/femot/pgoapi-go/api"
 	"github.com/urfave/cli"
 )
 
@@ -85,7 +87,34 @@ func Run(crypto api.Crypto, args []string) {
 		{
 			Name:   "map",
 			Usage:  "Retrieves map data for the player's current location",
-			Action: w.wrap(getMap),
+			Action: w.wrap(w.getMap),
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "format,f",
+					Destination: &w.format,
+					Value:       "json",
+					Usage:       "Output format can be either \"json\", \"geojson\", \"kml\" or \"gpx\"",
+				},
+			},
+		},
+		{
+			Name:   "web",
+			Usage:  "Serves a live map of the player's surroundings, announcing the player's presence periodically",
+			Action: w.wrap(w.web),
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:        "listen,l",
+					Destination: &w.listen,
+					Value:       "localhost:8080",
+					Usage:       "Address to serve the map on",
+				},
+				cli.DurationFlag{
+					Name:        "interval,i",
+					Destination: &w.interval,
+					Value:       10 * time.Second,
+					Usage:       "Time between announces",
+				},
+			},
 		},
 	}
 
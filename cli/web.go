@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/auth"
+	"github.com/femot/pgoapi-go/events"
+	"github.com/femot/pgoapi-go/export"
+	"github.com/femot/pgoapi-go/live"
+)
+
+// mapView keeps the latest map objects and the location they were announced from
+type mapView struct {
+	mutex      sync.RWMutex
+	location   api.Location
+	updated    time.Time
+	announces  int
+	mapObjects *protos.GetMapObjectsResponse
+}
+
+type viewState struct {
+	Location  api.Location `json:"location"`
+	Updated   *time.Time   `json:"updated"`
+	Announces int          `json:"announces"`
+	Cells     []string     `json:"cells"`
+}
+
+// Push keeps the map objects of a response pushed without details
+func (v *mapView) Push(entry interface{}) {
+	if mapObjects, ok := entry.(*protos.GetMapObjectsResponse); ok {
+		v.PushEntry(&api.FeedEntry{Timestamp: time.Now(), Message: mapObjects})
+	}
+}
+
+// PushEntry keeps the map objects of an announce along with its location
+func (v *mapView) PushEntry(entry *api.FeedEntry) {
+	mapObjects, ok := entry.Message.(*protos.GetMapObjectsResponse)
+	if !ok {
+		return
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if entry.Location != (api.Location{}) {
+		v.location = entry.Location
+	}
+	v.updated = entry.Timestamp
+	v.announces++
+	v.mapObjects = mapObjects
+}
+
+// cellIDs returns the cells of the latest response, which are the ones requested by the announce
+func (v *mapView) cellIDs() []uint64 {
+	cellIDs := make([]uint64, 0)
+	for _, cell := range v.mapObjects.GetMapCells() {
+		cellIDs = append(cellIDs, cell.S2CellId)
+	}
+	return cellIDs
+}
+
+func (v *mapView) serveState(w http.ResponseWriter, r *http.Request) {
+	v.mutex.RLock()
+	state := viewState{
+		Location:  v.location,
+		Announces: v.announces,
+		Cells:     make([]string, 0),
+	}
+	if !v.updated.IsZero() {
+		updated := v.updated
+		state.Updated = &updated
+	}
+	// Cell ids are sent as strings as they do not fit in a JavaScript number
+	for _, cellID := range v.cellIDs() {
+		state.Cells = append(state.Cells, strconv.FormatUint(cellID, 10))
+	}
+	v.mutex.RUnlock()
+
+	b, err := json.Marshal(state)
+	serveJSON(w, b, err)
+}
+
+func (v *mapView) serveMap(w http.ResponseWriter, r *http.Request) {
+	v.mutex.RLock()
+	features := export.Features(v.mapObjects, v.cellIDs())
+	v.mutex.RUnlock()
+
+	b, err := export.GeoJSON(features)
+	serveJSON(w, b, err)
+}
+
+func serveJSON(w http.ResponseWriter, b []byte, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (v *mapView) handler(broadcaster *live.Broadcaster) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, webPage)
+	})
+	mux.HandleFunc("/api/state", v.serveState)
+	mux.HandleFunc("/api/map", v.serveMap)
+	mux.Handle("/live/", http.StripPrefix("/live", broadcaster.Handler()))
+	return mux
+}
+
+func (w *wrapper) web(ctx context.Context, session *api.Session, provider auth.Provider) error {
+	view := &mapView{location: *w.location()}
+	broadcaster := live.NewBroadcaster()
+	differ := events.NewDiffer()
+	differ.Handle(broadcaster)
+	session.SetEntryFeed(api.NewMultiFeed(view, broadcaster, differ))
+
+	err := session.Init(ctx, -1)
+	if isFailure(err) {
+		return fail(err)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- http.ListenAndServe(w.listen, view.handler(broadcaster))
+	}()
+	fmt.Fprintf(os.Stderr, "Serving the map on http://%s/\n", w.listen)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		_, err := session.Announce(ctx, -1)
+		if isFailure(err) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		select {
+		case err := <-served:
+			return fail(err)
+		case <-ticker.C:
+		}
+	}
+}
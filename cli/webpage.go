@@ -0,0 +1,145 @@
+package cli
+
+// webPage is the map viewer served by the web command, it draws the latest map objects
+// and reloads them whenever the live feed reports a new response
+const webPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>pgoapi-go</title>
+<style>
+body { margin: 0; font-family: sans-serif; display: flex; height: 100vh; }
+#map { flex: 1; background: #eef2e6; }
+#side { width: 320px; overflow-y: auto; padding: 12px; font-size: 13px; border-left: 1px solid #ccc; }
+h2 { font-size: 15px; margin: 16px 0 4px; }
+ul { margin: 0; padding-left: 18px; }
+.cell { fill: rgba(60, 90, 200, 0.08); stroke: #3c5ac8; stroke-width: 1; vector-effect: non-scaling-stroke; }
+.pokestop { fill: #2a9df4; }
+.gym { stroke: #333; stroke-width: 1; vector-effect: non-scaling-stroke; }
+.NEUTRAL { fill: #aaa; } .BLUE { fill: #2060e0; } .RED { fill: #e02020; } .YELLOW { fill: #f0c000; }
+.pokemon { fill: #e0508a; }
+.approximate { fill: none; stroke: #e0508a; stroke-dasharray: 2; vector-effect: non-scaling-stroke; }
+.spawn_point { fill: #777; }
+.player { fill: #000; }
+</style>
+</head>
+<body>
+<svg id="map"></svg>
+<div id="side">
+<div id="status">Waiting for the first announce…</div>
+<h2>Pokémon</h2><ul id="pokemon"></ul>
+<h2>Gyms</h2><ul id="gyms"></ul>
+<h2>Pokéstops</h2><ul id="pokestops"></ul>
+</div>
+<script>
+var svg = document.getElementById("map");
+var ns = "http://www.w3.org/2000/svg";
+
+function project(lon, lat, origin) {
+  var x = (lon - origin[0]) * Math.cos(origin[1] * Math.PI / 180);
+  return [x, origin[1] - lat];
+}
+
+function element(name, attributes, title) {
+  var e = document.createElementNS(ns, name);
+  for (var key in attributes) {
+    e.setAttribute(key, attributes[key]);
+  }
+  if (title) {
+    var t = document.createElementNS(ns, "title");
+    t.textContent = title;
+    e.appendChild(t);
+  }
+  return e;
+}
+
+function list(id, items) {
+  var ul = document.getElementById(id);
+  ul.innerHTML = "";
+  items.forEach(function (text) {
+    var li = document.createElement("li");
+    li.textContent = text;
+    ul.appendChild(li);
+  });
+}
+
+function draw(state, collection) {
+  var origin = [state.location.Lon, state.location.Lat];
+  var minX = Infinity, minY = Infinity, maxX = -Infinity, maxY = -Infinity;
+  function extend(p) {
+    minX = Math.min(minX, p[0]); maxX = Math.max(maxX, p[0]);
+    minY = Math.min(minY, p[1]); maxY = Math.max(maxY, p[1]);
+  }
+  extend(project(origin[0], origin[1], origin));
+  collection.features.forEach(function (f) {
+    if (f.geometry.type === "Polygon") {
+      f.geometry.coordinates[0].forEach(function (c) { extend(project(c[0], c[1], origin)); });
+    } else {
+      extend(project(f.geometry.coordinates[0], f.geometry.coordinates[1], origin));
+    }
+  });
+  var margin = Math.max(maxX - minX, maxY - minY, 0.001) * 0.05;
+  var width = maxX - minX + 2 * margin, height = maxY - minY + 2 * margin;
+  var radius = Math.max(width, height) / 150;
+  svg.setAttribute("viewBox", [minX - margin, minY - margin, width, height].join(" "));
+  svg.innerHTML = "";
+
+  var pokemon = [], gyms = [], pokestops = [];
+  collection.features.forEach(function (f) {
+    var p = f.properties;
+    if (f.geometry.type === "Polygon") {
+      var points = f.geometry.coordinates[0].map(function (c) { return project(c[0], c[1], origin).join(","); });
+      svg.appendChild(element("polygon", {points: points.join(" "), "class": "cell"}, "Cell " + p.cell_id));
+      return;
+    }
+    var at = project(f.geometry.coordinates[0], f.geometry.coordinates[1], origin);
+    var attributes = {cx: at[0], cy: at[1], r: radius, "class": p.type};
+    var title = p.name;
+    switch (p.type) {
+    case "gym":
+      attributes["class"] = "gym " + p.team;
+      title = "Gym " + p.name + " (" + p.team + ", " + p.gym_points + " prestige)";
+      gyms.push(title);
+      break;
+    case "pokestop":
+      title = "Pokéstop " + p.name + (p.lure_expires_ms ? " (lured)" : "");
+      pokestops.push(title);
+      break;
+    case "wild_pokemon":
+    case "catchable_pokemon":
+    case "nearby_pokemon":
+      attributes["class"] = p.approximate ? "approximate" : "pokemon";
+      title = p.name + (p.expires_ms ? " until " + new Date(p.expires_ms).toLocaleTimeString() : "");
+      pokemon.push(title);
+      break;
+    case "spawn_point":
+      attributes.r = radius / 2;
+      break;
+    }
+    svg.appendChild(element("circle", attributes, title));
+  });
+  svg.appendChild(element("circle", {cx: 0, cy: 0, r: radius * 1.5, "class": "player"}, "Player"));
+
+  list("pokemon", pokemon);
+  list("gyms", gyms);
+  list("pokestops", pokestops);
+  document.getElementById("status").textContent = state.updated
+    ? state.announces + " announces, last at " + new Date(state.updated).toLocaleTimeString() +
+      " from " + state.location.Lat.toFixed(6) + ", " + state.location.Lon.toFixed(6) +
+      " covering " + state.cells.length + " cells"
+    : "Waiting for the first announce…";
+}
+
+function refresh() {
+  Promise.all([
+    fetch("api/state").then(function (r) { return r.json(); }),
+    fetch("api/map").then(function (r) { return r.json(); })
+  ]).then(function (results) { draw(results[0], results[1]); });
+}
+
+refresh();
+new EventSource("live/events?types=GetMapObjectsResponse").onmessage = refresh;
+</script>
+</body>
+</html>
+`
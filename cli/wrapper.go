@@ -1,12 +1,14 @@
 package cli
 
 import (
+	"time"
+
 	"golang.org/x/net/context"
 
 	"github.com/urfave/cli"
 
-	"github.com/pogodevorg/pgoapi-go/api"
-	"github.com/pogodevorg/pgoapi-go/auth"
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/auth"
 )
 
 type wrapper struct {
@@ -21,6 +23,20 @@ type wrapper struct {
 
 	debug  bool
 	crypto api.Crypto
+
+	format string
+
+	listen   string
+	interval time.Duration
+}
+
+func (w *wrapper) location() *api.Location {
+	return &api.Location{
+		Lon:      w.lon,
+		Lat:      w.lat,
+		Alt:      w.alt,
+		Accuracy: w.accuracy,
+	}
 }
 
 func (w *wrapper) wrap(action func(context.Context, *api.Session, auth.Provider) error) func(*cli.Context) error {
@@ -33,14 +49,7 @@ func (w *wrapper) wrap(action func(context.Context, *api.Session, auth.Provider)
 			return cli.NewExitError(err.Error(), 1)
 		}
 
-		location := &api.Location{
-			Lon:      w.lon,
-			Lat:      w.lat,
-			Alt:      w.alt,
-			Accuracy: w.accuracy,
-		}
-
-		client := api.NewSession(provider, location, &api.VoidFeed{}, w.crypto, w.debug)
+		client := api.NewSession(provider, w.location(), &api.VoidFeed{}, w.crypto, w.debug)
 
 		return action(ctx, client, provider)
 	}
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+type encounterIDs []uint64
+
+func (a encounterIDs) Len() int           { return len(a) }
+func (a encounterIDs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a encounterIDs) Less(i, j int) bool { return a[i] < a[j] }
+
+type cellState struct {
+	pokemon map[uint64]*protos.WildPokemon
+	forts   map[string]*protos.FortData
+}
+
+// Differ is a feed keeping the last known state of every map cell,
+// passing the changes of every map response to its handlers
+//
+// Pokémon are always sent in full and disappear when they are missing from a later scan of their cell,
+// while forts are only removed when the server reports them as deleted.
+type Differ struct {
+	mutex    sync.Mutex
+	cells    map[uint64]*cellState
+	handlers []Handler
+}
+
+// NewDiffer constructs a differ that has not seen any cells yet
+func NewDiffer() *Differ {
+	return &Differ{
+		cells: make(map[uint64]*cellState),
+	}
+}
+
+// Handle registers a handler to be called with every event, in the order the handlers were registered
+func (d *Differ) Handle(h Handler) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.handlers = append(d.handlers, h)
+}
+
+// Push compares map responses to the last known state and passes the events to the handlers
+func (d *Differ) Push(entry interface{}) {
+	mapObjects, ok := entry.(*protos.GetMapObjectsResponse)
+	if !ok {
+		return
+	}
+
+	events := d.Diff(mapObjects)
+
+	d.mutex.Lock()
+	handlers := d.handlers
+	d.mutex.Unlock()
+
+	for _, event := range events {
+		for _, h := range handlers {
+			h.Handle(event)
+		}
+	}
+}
+
+// Diff updates the last known state with a map response and returns the events it caused
+func (d *Differ) Diff(mapObjects *protos.GetMapObjectsResponse) []Event {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	events := make([]Event, 0)
+	for _, cell := range mapObjects.GetMapCells() {
+		state, ok := d.cells[cell.S2CellId]
+		if !ok {
+			state = &cellState{
+				pokemon: make(map[uint64]*protos.WildPokemon),
+				forts:   make(map[string]*protos.FortData),
+			}
+			d.cells[cell.S2CellId] = state
+		}
+		events = append(events, state.diffPokemon(cell)...)
+		events = append(events, state.diffForts(cell)...)
+	}
+	return events
+}
+
+func (s *cellState) diffPokemon(cell *protos.MapCell) []Event {
+	events := make([]Event, 0)
+
+	seen := make(map[uint64]bool, len(cell.WildPokemons))
+	for _, pokemon := range cell.WildPokemons {
+		seen[pokemon.EncounterId] = true
+		if _, ok := s.pokemon[pokemon.EncounterId]; !ok {
+			events = append(events, &PokemonAppeared{CellID: cell.S2CellId, Pokemon: pokemon})
+		}
+		s.pokemon[pokemon.EncounterId] = pokemon
+	}
+
+	// A truncated list may leave out Pokémon that are still there
+	if cell.IsTruncatedList {
+		return events
+	}
+	despawned := make(encounterIDs, 0)
+	for id := range s.pokemon {
+		if !seen[id] {
+			despawned = append(despawned, id)
+		}
+	}
+	sort.Sort(despawned)
+	for _, id := range despawned {
+		events = append(events, &PokemonDespawned{CellID: cell.S2CellId, Pokemon: s.pokemon[id]})
+		delete(s.pokemon, id)
+	}
+	return events
+}
+
+func (s *cellState) diffForts(cell *protos.MapCell) []Event {
+	events := make([]Event, 0)
+
+	for _, fort := range cell.Forts {
+		// A lure that already ran out is left out, it was either reported as expired before or never seen running
+		if lureExpired(fort, cell) {
+			fort = unlured(fort)
+		}
+
+		previous, ok := s.forts[fort.Id]
+		s.forts[fort.Id] = fort
+		if !ok {
+			events = append(events, &FortAdded{CellID: cell.S2CellId, Fort: fort})
+			if fort.LureInfo != nil {
+				events = append(events, &FortLured{CellID: cell.S2CellId, Fort: fort, Lure: fort.LureInfo})
+			}
+			continue
+		}
+
+		switch {
+		case fort.LureInfo != nil && (previous.LureInfo == nil || previous.LureInfo.LureExpiresTimestampMs != fort.LureInfo.LureExpiresTimestampMs):
+			events = append(events, &FortLured{CellID: cell.S2CellId, Fort: fort, Lure: fort.LureInfo})
+		case fort.LureInfo == nil && previous.LureInfo != nil:
+			events = append(events, &LureExpired{CellID: cell.S2CellId, Fort: fort, Lure: previous.LureInfo})
+		}
+
+		if fort.Type == protos.FortType_GYM {
+			if fort.OwnedByTeam != previous.OwnedByTeam {
+				events = append(events, &GymTeamChanged{CellID: cell.S2CellId, Fort: fort, Previous: previous.OwnedByTeam})
+			}
+			if fort.GymPoints != previous.GymPoints {
+				events = append(events, &GymPrestigeChanged{CellID: cell.S2CellId, Fort: fort, Previous: previous.GymPoints})
+			}
+		}
+	}
+
+	for _, id := range cell.DeletedObjects {
+		if fort, ok := s.forts[id]; ok {
+			events = append(events, &FortRemoved{CellID: cell.S2CellId, Fort: fort})
+			delete(s.forts, id)
+		}
+	}
+
+	// Forts are only sent when they change, so lures running out have to be noticed by their expiry
+	expired := make([]string, 0)
+	for id, fort := range s.forts {
+		if lureExpired(fort, cell) {
+			expired = append(expired, id)
+		}
+	}
+	sort.Strings(expired)
+	for _, id := range expired {
+		fort := s.forts[id]
+		events = append(events, &LureExpired{CellID: cell.S2CellId, Fort: fort, Lure: fort.LureInfo})
+		s.forts[id] = unlured(fort)
+	}
+
+	return events
+}
+
+func lureExpired(fort *protos.FortData, cell *protos.MapCell) bool {
+	return fort.LureInfo != nil && fort.LureInfo.LureExpiresTimestampMs <= cell.CurrentTimestampMs
+}
+
+func unlured(fort *protos.FortData) *protos.FortData {
+	clone := proto.Clone(fort).(*protos.FortData)
+	clone.LureInfo = nil
+	return clone
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"strings"
+	"testing"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+func cell(timestampMs int64, pokemon []*protos.WildPokemon, forts ...*protos.FortData) *protos.GetMapObjectsResponse {
+	return &protos.GetMapObjectsResponse{
+		MapCells: []*protos.MapCell{{
+			S2CellId:           1,
+			CurrentTimestampMs: timestampMs,
+			WildPokemons:       pokemon,
+			Forts:              forts,
+		}},
+	}
+}
+
+func kinds(events []Event) map[string]int {
+	k := make(map[string]int)
+	for _, event := range events {
+		k[event.Kind()]++
+	}
+	return k
+}
+
+func expectKinds(t *testing.T, events []Event, expected map[string]int) {
+	actual := kinds(events)
+	if len(actual) != len(expected) {
+		t.Errorf("expected events %v, got %v", expected, actual)
+		return
+	}
+	for kind, count := range expected {
+		if actual[kind] != count {
+			t.Errorf("expected events %v, got %v", expected, actual)
+			return
+		}
+	}
+}
+
+func TestPokemonEvents(t *testing.T) {
+	d := NewDiffer()
+	pidgey := &protos.WildPokemon{EncounterId: 1}
+	rattata := &protos.WildPokemon{EncounterId: 2}
+
+	expectKinds(t, d.Diff(cell(1000, []*protos.WildPokemon{pidgey})), map[string]int{"pokemon_appeared": 1})
+	expectKinds(t, d.Diff(cell(2000, []*protos.WildPokemon{pidgey, rattata})), map[string]int{"pokemon_appeared": 1})
+
+	// Truncated lists do not despawn the missing Pokémon
+	truncated := cell(3000, []*protos.WildPokemon{rattata})
+	truncated.MapCells[0].IsTruncatedList = true
+	expectKinds(t, d.Diff(truncated), map[string]int{})
+
+	events := d.Diff(cell(4000, []*protos.WildPokemon{rattata}))
+	expectKinds(t, events, map[string]int{"pokemon_despawned": 1})
+	if events[0].(*PokemonDespawned).Pokemon.EncounterId != 1 {
+		t.Errorf("expected the first Pokémon to despawn")
+	}
+}
+
+func TestFortEvents(t *testing.T) {
+	d := NewDiffer()
+	gym := &protos.FortData{Id: "gym", Type: protos.FortType_GYM, OwnedByTeam: protos.TeamColor_BLUE, GymPoints: 1000}
+	stop := &protos.FortData{Id: "stop", Type: protos.FortType_CHECKPOINT}
+
+	expectKinds(t, d.Diff(cell(1000, nil, gym, stop)), map[string]int{"fort_added": 2})
+
+	// Forts missing from a later scan did not change
+	expectKinds(t, d.Diff(cell(2000, nil)), map[string]int{})
+
+	taken := &protos.FortData{Id: "gym", Type: protos.FortType_GYM, OwnedByTeam: protos.TeamColor_RED, GymPoints: 500}
+	lured := &protos.FortData{Id: "stop", Type: protos.FortType_CHECKPOINT, LureInfo: &protos.FortLureInfo{LureExpiresTimestampMs: 5000}}
+	events := d.Diff(cell(3000, nil, taken, lured))
+	expectKinds(t, events, map[string]int{"gym_team_changed": 1, "gym_prestige_changed": 1, "fort_lured": 1})
+	for _, event := range events {
+		if e, ok := event.(*GymTeamChanged); ok && e.Previous != protos.TeamColor_BLUE {
+			t.Errorf("expected the gym to be taken from team BLUE, got %v", e.Previous)
+		}
+	}
+
+	expectKinds(t, d.Diff(cell(5000, nil)), map[string]int{"lure_expired": 1})
+
+	deleted := cell(6000, nil)
+	deleted.MapCells[0].DeletedObjects = []string{"stop", "unknown"}
+	expectKinds(t, d.Diff(deleted), map[string]int{"fort_removed": 1})
+}
+
+func TestExpiredLures(t *testing.T) {
+	d := NewDiffer()
+	expired := &protos.FortLureInfo{LureExpiresTimestampMs: 1000}
+	running := &protos.FortLureInfo{LureExpiresTimestampMs: 5000}
+
+	// Lures that ran out before the forts were seen are not reported
+	events := d.Diff(cell(2000, nil, &protos.FortData{Id: "a", LureInfo: expired}))
+	expectKinds(t, events, map[string]int{"fort_added": 1})
+	if events[0].(*FortAdded).Fort.LureInfo != nil {
+		t.Error("expected the added fort to have no lure")
+	}
+	expectKinds(t, d.Diff(cell(2000, nil, &protos.FortData{Id: "a", LureInfo: &protos.FortLureInfo{LureExpiresTimestampMs: 1500}})), map[string]int{})
+
+	// A running lure reported as ran out expires once
+	expectKinds(t, d.Diff(cell(3000, nil, &protos.FortData{Id: "b"}, &protos.FortData{Id: "c"})), map[string]int{"fort_added": 2})
+	expectKinds(t, d.Diff(cell(3000, nil, &protos.FortData{Id: "b", LureInfo: running}, &protos.FortData{Id: "c", LureInfo: running})), map[string]int{"fort_lured": 2})
+	expectKinds(t, d.Diff(cell(6000, nil, &protos.FortData{Id: "c", LureInfo: running})), map[string]int{"lure_expired": 2})
+	expectKinds(t, d.Diff(cell(7000, nil)), map[string]int{})
+}
+
+func TestEventOrder(t *testing.T) {
+	d := NewDiffer()
+	pokemon := make([]*protos.WildPokemon, 0)
+	forts := make([]*protos.FortData, 0)
+	for _, id := range []uint64{9, 3, 7, 1, 5} {
+		pokemon = append(pokemon, &protos.WildPokemon{EncounterId: id})
+		forts = append(forts, &protos.FortData{Id: string('a' + rune(id)), LureInfo: &protos.FortLureInfo{LureExpiresTimestampMs: 2000}})
+	}
+	d.Diff(cell(1000, pokemon, forts...))
+
+	events := d.Diff(cell(3000, nil))
+	order := make([]string, 0)
+	for _, event := range events {
+		switch e := event.(type) {
+		case *PokemonDespawned:
+			order = append(order, string('a'+rune(e.Pokemon.EncounterId)))
+		case *LureExpired:
+			order = append(order, e.Fort.Id)
+		}
+	}
+	if strings.Join(order, "") != "bdfhjbdfhj" {
+		t.Errorf("expected the despawns and then the expired lures by id, got %v", order)
+	}
+}
+
+func TestPushHandlers(t *testing.T) {
+	d := NewDiffer()
+	received := make([]string, 0)
+	d.Handle(HandlerFunc(func(event Event) {
+		received = append(received, "first:"+event.Kind())
+	}))
+	d.Handle(HandlerFunc(func(event Event) {
+		received = append(received, "second:"+event.Kind())
+	}))
+
+	d.Push(&protos.GetPlayerResponse{})
+	d.Push(cell(1000, []*protos.WildPokemon{{EncounterId: 1}}))
+
+	if len(received) != 2 || received[0] != "first:pokemon_appeared" || received[1] != "second:pokemon_appeared" {
+		t.Errorf("expected both handlers to receive the event in order, got %v", received)
+	}
+}
@@ -0,0 +1,99 @@
+// Package events compares consecutive map scans and turns the changes in to typed events
+package events
+
+import protos "github.com/pogodevorg/POGOProtos-go"
+
+// Event is a change of the map between two scans
+type Event interface {
+	// Kind returns the name of the event
+	Kind() string
+}
+
+// PokemonAppeared happens when a wild Pokémon is seen for the first time
+type PokemonAppeared struct {
+	CellID  uint64
+	Pokemon *protos.WildPokemon
+}
+
+// Kind returns "pokemon_appeared"
+func (e *PokemonAppeared) Kind() string { return "pokemon_appeared" }
+
+// PokemonDespawned happens when a wild Pokémon is no longer seen in its cell
+type PokemonDespawned struct {
+	CellID  uint64
+	Pokemon *protos.WildPokemon
+}
+
+// Kind returns "pokemon_despawned"
+func (e *PokemonDespawned) Kind() string { return "pokemon_despawned" }
+
+// FortAdded happens when a fort is seen for the first time
+type FortAdded struct {
+	CellID uint64
+	Fort   *protos.FortData
+}
+
+// Kind returns "fort_added"
+func (e *FortAdded) Kind() string { return "fort_added" }
+
+// FortRemoved happens when the server reports a fort as deleted
+type FortRemoved struct {
+	CellID uint64
+	Fort   *protos.FortData
+}
+
+// Kind returns "fort_removed"
+func (e *FortRemoved) Kind() string { return "fort_removed" }
+
+// FortLured happens when a lure module is put on a Pokéstop
+type FortLured struct {
+	CellID uint64
+	Fort   *protos.FortData
+	Lure   *protos.FortLureInfo
+}
+
+// Kind returns "fort_lured"
+func (e *FortLured) Kind() string { return "fort_lured" }
+
+// LureExpired happens when the lure module of a Pokéstop runs out
+type LureExpired struct {
+	CellID uint64
+	Fort   *protos.FortData
+	Lure   *protos.FortLureInfo
+}
+
+// Kind returns "lure_expired"
+func (e *LureExpired) Kind() string { return "lure_expired" }
+
+// GymTeamChanged happens when a gym is taken over by another team or left neutral
+type GymTeamChanged struct {
+	CellID   uint64
+	Fort     *protos.FortData
+	Previous protos.TeamColor
+}
+
+// Kind returns "gym_team_changed"
+func (e *GymTeamChanged) Kind() string { return "gym_team_changed" }
+
+// GymPrestigeChanged happens when the prestige of a gym goes up or down
+type GymPrestigeChanged struct {
+	CellID   uint64
+	Fort     *protos.FortData
+	Previous int64
+}
+
+// Kind returns "gym_prestige_changed"
+func (e *GymPrestigeChanged) Kind() string { return "gym_prestige_changed" }
+
+// Handler is a common interface for acting on events
+type Handler interface {
+	Handle(event Event)
+}
+
+// HandlerFunc is a function acting on events
+type HandlerFunc func(event Event)
+
+// Handle calls the function
+func (f HandlerFunc) Handle(event Event) {
+	f(event)
+}
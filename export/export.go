@@ -0,0 +1,44 @@
+package export
+
+import (
+	"errors"
+	"strings"
+)
+
+// Format is an output format for features
+type Format string
+
+// Supported output formats
+const (
+	FormatGeoJSON Format = "geojson"
+	FormatKML     Format = "kml"
+	FormatGPX     Format = "gpx"
+)
+
+// ErrUnknownFormat happens when a format is not supported
+var ErrUnknownFormat = errors.New("export: Unknown format")
+
+// ParseFormat returns the format with the given name, ignoring case
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	switch format {
+	case FormatGeoJSON, FormatKML, FormatGPX:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// Encode converts the features to the format
+func Encode(format Format, features []*Feature) ([]byte, error) {
+	switch format {
+	case FormatGeoJSON:
+		return GeoJSON(features)
+	case FormatKML:
+		return KML(features)
+	case FormatGPX:
+		return GPX(features)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+var cellID = uint64(s2.CellIDFromLatLng(s2.LatLngFromDegrees(52.52, 13.405)).Parent(15))
+
+func mapObjects() *protos.GetMapObjectsResponse {
+	return &protos.GetMapObjectsResponse{
+		MapCells: []*protos.MapCell{{
+			S2CellId:           cellID,
+			CurrentTimestampMs: 1000,
+			Forts: []*protos.FortData{
+				{Id: "gym", Latitude: 52.52, Longitude: 13.405, Type: protos.FortType_GYM, OwnedByTeam: protos.TeamColor_BLUE},
+				{Id: "stop", Latitude: 52.521, Longitude: 13.406, Type: protos.FortType_CHECKPOINT, Enabled: true},
+			},
+			WildPokemons: []*protos.WildPokemon{
+				{EncounterId: 1, Latitude: 52.52, Longitude: 13.404, LastModifiedTimestampMs: 1000, TimeTillHiddenMs: 5000, PokemonData: &protos.PokemonData{PokemonId: protos.PokemonId_PIDGEY}},
+			},
+			CatchablePokemons: []*protos.MapPokemon{
+				{EncounterId: 1, Latitude: 52.52, Longitude: 13.404, PokemonId: protos.PokemonId_PIDGEY, ExpirationTimestampMs: 6000},
+			},
+			NearbyPokemons: []*protos.NearbyPokemon{
+				{EncounterId: 2, PokemonId: protos.PokemonId_RATTATA, FortId: "stop"},
+				{EncounterId: 3, PokemonId: protos.PokemonId_PIKACHU},
+			},
+			SpawnPoints: []*protos.SpawnPoint{{Latitude: 52.52, Longitude: 13.404}},
+		}},
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	features := Features(mapObjects(), []uint64{cellID})
+	kinds := make(map[Kind]int)
+	for _, feature := range features {
+		kinds[feature.Kind]++
+	}
+	expected := map[Kind]int{
+		KindGym: 1, KindPokestop: 1, KindWildPokemon: 1, KindCatchablePokemon: 1,
+		KindNearbyPokemon: 2, KindSpawnPoint: 1, KindCell: 1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("expected %d %s features, got %d", count, kind, kinds[kind])
+		}
+	}
+
+	for _, feature := range features {
+		switch feature.ID {
+		case "gym":
+			if feature.Properties["team"] != "BLUE" {
+				t.Errorf("expected the gym to be owned by team BLUE, got %v", feature.Properties["team"])
+			}
+		case "1":
+			if feature.Properties["expires_ms"] != int64(6000) {
+				t.Errorf("expected %s to expire at 6000, got %v", feature.Kind, feature.Properties["expires_ms"])
+			}
+		case "2":
+			if feature.Point.Lat != 52.521 || feature.Properties["fort_id"] != "stop" {
+				t.Errorf("expected the nearby Pokémon to be placed at its fort, got %v", feature.Point)
+			}
+		case "3":
+			if feature.Properties["approximate"] != true {
+				t.Errorf("expected the nearby Pokémon without fort to be approximate")
+			}
+		}
+		if feature.Kind == KindCell && (len(feature.Polygon) != 5 || feature.Polygon[0] != feature.Polygon[4]) {
+			t.Errorf("expected the cell to be a closed ring, got %v", feature.Polygon)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	features := Features(mapObjects(), []uint64{cellID})
+
+	b, err := Encode(FormatGeoJSON, features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection GeoJSONFeatureCollection
+	if err := json.Unmarshal(b, &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != len(features) {
+		t.Errorf("expected %d GeoJSON features, got %d", len(features), len(collection.Features))
+	}
+
+	b, err = Encode(FormatKML, features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc kml
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Folders) != 7 {
+		t.Errorf("expected a KML folder for every kind, got %d", len(doc.Folders))
+	}
+	styles := make(map[string]bool)
+	for _, style := range doc.Styles {
+		styles["#"+style.ID] = true
+	}
+	for _, folder := range doc.Folders {
+		for _, placemark := range folder.Placemarks {
+			if !styles[placemark.StyleURL] {
+				t.Errorf("expected the style %s of %s to be defined", placemark.StyleURL, placemark.ID)
+			}
+			if strings.ContainsAny(placemark.ID, ",: ") {
+				t.Errorf("expected %q to be a valid XML id", placemark.ID)
+			}
+		}
+	}
+
+	b, err = Encode(FormatGPX, features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var waypoints GPXDocument
+	if err := xml.Unmarshal(b, &waypoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(waypoints.Waypoints) != len(features)-1 {
+		t.Errorf("expected a GPX waypoint for every feature but the cell, got %d", len(waypoints.Waypoints))
+	}
+	expiring := 0
+	for _, waypoint := range waypoints.Waypoints {
+		if waypoint.Time != "" {
+			t.Errorf("expected %s to have no time, got %s", waypoint.Description, waypoint.Time)
+		}
+		if strings.HasSuffix(waypoint.Description, " expires 1970-01-01T00:00:06Z") {
+			expiring++
+		}
+	}
+	if expiring == 0 {
+		t.Error("expected the Pokémon expiry in the waypoint descriptions")
+	}
+
+	if _, err := ParseFormat("shapefile"); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
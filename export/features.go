@@ -0,0 +1,212 @@
+// Package export converts map objects to GeoJSON, KML and GPX
+package export
+
+import (
+	"strconv"
+
+	"github.com/golang/geo/s2"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// Kind is the type of map object a feature describes
+type Kind string
+
+// Kinds of features exported from map objects
+const (
+	KindGym              Kind = "gym"
+	KindPokestop         Kind = "pokestop"
+	KindWildPokemon      Kind = "wild_pokemon"
+	KindCatchablePokemon Kind = "catchable_pokemon"
+	KindNearbyPokemon    Kind = "nearby_pokemon"
+	KindSpawnPoint       Kind = "spawn_point"
+	KindCell             Kind = "cell"
+)
+
+// Point is a pair of coordinates in degrees
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Feature is a map object with a location and the properties worth showing on a map
+type Feature struct {
+	Kind Kind
+	ID   string
+	Name string
+	// Point is the location of the object, or the center of a cell
+	Point Point
+	// Polygon is the closed outline of a cell and empty for all other kinds
+	Polygon    []Point
+	Properties map[string]interface{}
+}
+
+// Features returns the forts, Pokémon and spawn points of the map cells,
+// followed by the outlines of the requested cells
+func Features(mapObjects *protos.GetMapObjectsResponse, cellIDs []uint64) []*Feature {
+	features := make([]*Feature, 0)
+	timestamps := make(map[uint64]int64)
+
+	for _, cell := range mapObjects.GetMapCells() {
+		timestamps[cell.S2CellId] = cell.CurrentTimestampMs
+
+		forts := make(map[string]*protos.FortData, len(cell.Forts))
+		for _, fort := range cell.Forts {
+			forts[fort.Id] = fort
+			features = append(features, fortFeature(fort))
+		}
+		for _, pokemon := range cell.WildPokemons {
+			features = append(features, wildPokemonFeature(pokemon))
+		}
+		for _, pokemon := range cell.CatchablePokemons {
+			features = append(features, catchablePokemonFeature(pokemon))
+		}
+		for _, pokemon := range cell.NearbyPokemons {
+			features = append(features, nearbyPokemonFeature(pokemon, forts[pokemon.FortId], cell.S2CellId))
+		}
+		for _, spawnPoint := range cell.SpawnPoints {
+			features = append(features, spawnPointFeature(spawnPoint, false))
+		}
+		for _, spawnPoint := range cell.DecimatedSpawnPoints {
+			features = append(features, spawnPointFeature(spawnPoint, true))
+		}
+	}
+
+	for _, cellID := range cellIDs {
+		feature := cellFeature(cellID)
+		if timestamp, ok := timestamps[cellID]; ok {
+			feature.Properties["timestamp_ms"] = timestamp
+		}
+		features = append(features, feature)
+	}
+
+	return features
+}
+
+func fortFeature(fort *protos.FortData) *Feature {
+	feature := &Feature{
+		ID:    fort.Id,
+		Name:  fort.Id,
+		Point: Point{Lat: fort.Latitude, Lon: fort.Longitude},
+		Properties: map[string]interface{}{
+			"enabled": fort.Enabled,
+		},
+	}
+
+	if fort.Type == protos.FortType_GYM {
+		feature.Kind = KindGym
+		feature.Properties["team"] = fort.OwnedByTeam.String()
+		feature.Properties["guard_pokemon"] = fort.GuardPokemonId.String()
+		feature.Properties["guard_pokemon_cp"] = fort.GuardPokemonCp
+		feature.Properties["gym_points"] = fort.GymPoints
+		feature.Properties["in_battle"] = fort.IsInBattle
+		return feature
+	}
+
+	feature.Kind = KindPokestop
+	if fort.CooldownCompleteTimestampMs > 0 {
+		feature.Properties["cooldown_complete_ms"] = fort.CooldownCompleteTimestampMs
+	}
+	if lure := fort.LureInfo; lure != nil {
+		feature.Properties["lure_expires_ms"] = lure.LureExpiresTimestampMs
+		feature.Properties["lure_pokemon"] = lure.ActivePokemonId.String()
+	}
+	return feature
+}
+
+func wildPokemonFeature(pokemon *protos.WildPokemon) *Feature {
+	name := pokemon.PokemonData.GetPokemonId().String()
+	feature := &Feature{
+		Kind:  KindWildPokemon,
+		ID:    strconv.FormatUint(pokemon.EncounterId, 10),
+		Name:  name,
+		Point: Point{Lat: pokemon.Latitude, Lon: pokemon.Longitude},
+		Properties: map[string]interface{}{
+			"pokemon":        name,
+			"encounter_id":   strconv.FormatUint(pokemon.EncounterId, 10),
+			"spawn_point_id": pokemon.SpawnPointId,
+		},
+	}
+	// The time until the Pokémon is hidden is only known shortly before it despawns
+	if pokemon.TimeTillHiddenMs > 0 {
+		feature.Properties["expires_ms"] = pokemon.LastModifiedTimestampMs + int64(pokemon.TimeTillHiddenMs)
+	}
+	return feature
+}
+
+func catchablePokemonFeature(pokemon *protos.MapPokemon) *Feature {
+	return &Feature{
+		Kind:  KindCatchablePokemon,
+		ID:    strconv.FormatUint(pokemon.EncounterId, 10),
+		Name:  pokemon.PokemonId.String(),
+		Point: Point{Lat: pokemon.Latitude, Lon: pokemon.Longitude},
+		Properties: map[string]interface{}{
+			"pokemon":        pokemon.PokemonId.String(),
+			"encounter_id":   strconv.FormatUint(pokemon.EncounterId, 10),
+			"spawn_point_id": pokemon.SpawnPointId,
+			"expires_ms":     pokemon.ExpirationTimestampMs,
+		},
+	}
+}
+
+// nearbyPokemonFeature places a nearby Pokémon at the fort it is seen at,
+// or else at the center of its cell as nearby Pokémon come without coordinates
+func nearbyPokemonFeature(pokemon *protos.NearbyPokemon, fort *protos.FortData, cellID uint64) *Feature {
+	feature := &Feature{
+		Kind: KindNearbyPokemon,
+		ID:   strconv.FormatUint(pokemon.EncounterId, 10),
+		Name: pokemon.PokemonId.String(),
+		Properties: map[string]interface{}{
+			"pokemon":      pokemon.PokemonId.String(),
+			"encounter_id": strconv.FormatUint(pokemon.EncounterId, 10),
+			"distance":     pokemon.DistanceInMeters,
+		},
+	}
+
+	if fort != nil {
+		feature.Point = Point{Lat: fort.Latitude, Lon: fort.Longitude}
+		feature.Properties["fort_id"] = fort.Id
+	} else {
+		center := s2.CellID(cellID).LatLng()
+		feature.Point = Point{Lat: center.Lat.Degrees(), Lon: center.Lng.Degrees()}
+		feature.Properties["approximate"] = true
+	}
+	return feature
+}
+
+func spawnPointFeature(spawnPoint *protos.SpawnPoint, decimated bool) *Feature {
+	id := strconv.FormatFloat(spawnPoint.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(spawnPoint.Longitude, 'f', -1, 64)
+	return &Feature{
+		Kind:  KindSpawnPoint,
+		ID:    id,
+		Name:  id,
+		Point: Point{Lat: spawnPoint.Latitude, Lon: spawnPoint.Longitude},
+		Properties: map[string]interface{}{
+			"decimated": decimated,
+		},
+	}
+}
+
+func cellFeature(cellID uint64) *Feature {
+	id := s2.CellID(cellID)
+	cell := s2.CellFromCellID(id)
+	center := id.LatLng()
+
+	polygon := make([]Point, 0, 5)
+	for k := 0; k < 4; k++ {
+		vertex := s2.LatLngFromPoint(cell.Vertex(k))
+		polygon = append(polygon, Point{Lat: vertex.Lat.Degrees(), Lon: vertex.Lng.Degrees()})
+	}
+	polygon = append(polygon, polygon[0])
+
+	return &Feature{
+		Kind:    KindCell,
+		ID:      id.ToToken(),
+		Name:    id.ToToken(),
+		Point:   Point{Lat: center.Lat.Degrees(), Lon: center.Lng.Degrees()},
+		Polygon: polygon,
+		Properties: map[string]interface{}{
+			"cell_id": strconv.FormatUint(cellID, 10),
+			"level":   id.Level(),
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import "encoding/json"
+
+// GeoJSONGeometry is a GeoJSON geometry, its coordinates are positions nested as deep as its type needs
+// and a geometry collection has geometries instead
+type GeoJSONGeometry struct {
+	Type        string             `json:"type"`
+	Coordinates interface{}        `json:"coordinates,omitempty"`
+	Geometries  []*GeoJSONGeometry `json:"geometries,omitempty"`
+}
+
+// GeoJSONFeature is a geometry with properties
+type GeoJSONFeature struct {
+	Type string `json:"type"`
+	// ID is a string or a number
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *GeoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONFeatureCollection is a list of features, with properties describing the collection as a whole
+type GeoJSONFeatureCollection struct {
+	Type       string                 `json:"type"`
+	Features   []*GeoJSONFeature      `json:"features"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// NewGeoJSONFeatureCollection returns an empty feature collection
+func NewGeoJSONFeatureCollection() *GeoJSONFeatureCollection {
+	return &GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*GeoJSONFeature, 0),
+	}
+}
+
+// Add appends a feature with the geometry and properties
+func (c *GeoJSONFeatureCollection) Add(geometry *GeoJSONGeometry, properties map[string]interface{}) *GeoJSONFeature {
+	feature := &GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geometry,
+		Properties: properties,
+	}
+	c.Features = append(c.Features, feature)
+	return feature
+}
+
+// GeoJSONPosition returns the coordinates in the longitude, latitude order of GeoJSON
+func GeoJSONPosition(lat, lon float64) []float64 {
+	return []float64{lon, lat}
+}
+
+// GeoJSON returns the features as a feature collection of points and cell polygons,
+// with the kind and name added to the properties
+func GeoJSON(features []*Feature) ([]byte, error) {
+	collection := NewGeoJSONFeatureCollection()
+
+	for _, feature := range features {
+		properties := make(map[string]interface{}, len(feature.Properties)+2)
+		for key, value := range feature.Properties {
+			properties[key] = value
+		}
+		properties["type"] = feature.Kind
+		properties["name"] = feature.Name
+
+		geometry := &GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: GeoJSONPosition(feature.Point.Lat, feature.Point.Lon),
+		}
+		if len(feature.Polygon) > 0 {
+			ring := make([][]float64, len(feature.Polygon))
+			for i, point := range feature.Polygon {
+				ring[i] = GeoJSONPosition(point.Lat, point.Lon)
+			}
+			geometry = &GeoJSONGeometry{
+				Type:        "Polygon",
+				Coordinates: [][][]float64{ring},
+			}
+		}
+
+		collection.Add(geometry, properties).ID = feature.ID
+	}
+
+	return json.Marshal(collection)
+}
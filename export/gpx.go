@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// GPXWaypoint is a GPX waypoint, or a point of a route
+type GPXWaypoint struct {
+	Lat         float64 `xml:"lat,attr"`
+	Lon         float64 `xml:"lon,attr"`
+	Time        string  `xml:"time,omitempty"`
+	Name        string  `xml:"name,omitempty"`
+	Description string  `xml:"desc,omitempty"`
+	Type        string  `xml:"type,omitempty"`
+}
+
+// GPXRoute is an ordered list of points leading to a destination
+type GPXRoute struct {
+	Name   string        `xml:"name,omitempty"`
+	Points []GPXWaypoint `xml:"rtept"`
+}
+
+// GPXDocument is a GPX 1.1 document of waypoints and routes
+type GPXDocument struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Waypoints []GPXWaypoint `xml:"wpt"`
+	Routes    []GPXRoute    `xml:"rte"`
+}
+
+// NewGPXDocument returns an empty document
+func NewGPXDocument() *GPXDocument {
+	return &GPXDocument{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "pgoapi-go",
+	}
+}
+
+// Marshal returns the document with an XML header
+func (d *GPXDocument) Marshal() ([]byte, error) {
+	b, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// GPX returns the features as waypoints, leaving out cells as GPX has no polygons
+//
+// Waypoints carry their identifier as description, followed by the expiry for Pokémon.
+// The time of a GPX waypoint is when it was recorded, so the expiry is left out of it.
+func GPX(features []*Feature) ([]byte, error) {
+	doc := NewGPXDocument()
+
+	for _, feature := range features {
+		if len(feature.Polygon) > 0 {
+			continue
+		}
+		waypoint := GPXWaypoint{
+			Lat:         feature.Point.Lat,
+			Lon:         feature.Point.Lon,
+			Name:        feature.Name,
+			Description: feature.ID,
+			Type:        string(feature.Kind),
+		}
+		if expires, ok := feature.Properties["expires_ms"].(int64); ok && expires > 0 {
+			waypoint.Description += " expires " + time.Unix(0, expires*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		}
+		doc.Waypoints = append(doc.Waypoints, waypoint)
+	}
+
+	return doc.Marshal()
+}
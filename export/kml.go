@@ -0,0 +1,137 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Coordinates string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlPlacemark struct {
+	ID           string      `xml:"id,attr,omitempty"`
+	Name         string      `xml:"name"`
+	StyleURL     string      `xml:"styleUrl"`
+	ExtendedData []kmlData   `xml:"ExtendedData>Data"`
+	Point        *kmlPoint   `xml:"Point,omitempty"`
+	Polygon      *kmlPolygon `xml:"Polygon,omitempty"`
+}
+
+type kmlColorStyle struct {
+	Color string `xml:"color"`
+}
+
+type kmlStyle struct {
+	ID        string         `xml:"id,attr"`
+	IconStyle *kmlColorStyle `xml:"IconStyle,omitempty"`
+	LineStyle *kmlColorStyle `xml:"LineStyle,omitempty"`
+	PolyStyle *kmlColorStyle `xml:"PolyStyle,omitempty"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kml struct {
+	XMLName xml.Name    `xml:"kml"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Styles  []kmlStyle  `xml:"Document>Style"`
+	Folders []kmlFolder `xml:"Document>Folder"`
+}
+
+// kmlColors are the colors of each kind of feature, as alpha, blue, green and red in hex
+var kmlColors = map[Kind]string{
+	KindGym:              "ff0000ff",
+	KindPokestop:         "ffff9900",
+	KindWildPokemon:      "ff8a50e0",
+	KindCatchablePokemon: "ff8a50e0",
+	KindNearbyPokemon:    "808a50e0",
+	KindSpawnPoint:       "ff777777",
+	KindCell:             "ffc85a3c",
+}
+
+func kmlStyleFor(kind Kind) kmlStyle {
+	color := kmlColors[kind]
+	if kind == KindCell {
+		// Cells are outlined with a faint fill so the features inside remain visible
+		return kmlStyle{ID: string(kind), LineStyle: &kmlColorStyle{color}, PolyStyle: &kmlColorStyle{"20" + color[2:]}}
+	}
+	return kmlStyle{ID: string(kind), IconStyle: &kmlColorStyle{color}}
+}
+
+// kmlID returns a valid XML id for a feature, replacing the characters of its id that may not be used
+func kmlID(kind Kind, id string) string {
+	return string(kind) + "-" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+func kmlCoordinates(points ...Point) string {
+	coordinates := make([]string, len(points))
+	for i, point := range points {
+		coordinates[i] = strconv.FormatFloat(point.Lon, 'f', -1, 64) + "," + strconv.FormatFloat(point.Lat, 'f', -1, 64)
+	}
+	return strings.Join(coordinates, " ")
+}
+
+// KML returns the features as placemarks grouped in a folder per kind,
+// with the properties as extended data and a style per kind
+func KML(features []*Feature) ([]byte, error) {
+	doc := kml{Xmlns: "http://www.opengis.net/kml/2.2"}
+	folders := make(map[Kind]int)
+
+	for _, feature := range features {
+		keys := make([]string, 0, len(feature.Properties))
+		for key := range feature.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		placemark := kmlPlacemark{
+			ID:           kmlID(feature.Kind, feature.ID),
+			Name:         feature.Name,
+			StyleURL:     "#" + string(feature.Kind),
+			ExtendedData: make([]kmlData, len(keys)),
+		}
+		for i, key := range keys {
+			placemark.ExtendedData[i] = kmlData{Name: key, Value: fmt.Sprint(feature.Properties[key])}
+		}
+		if len(feature.Polygon) > 0 {
+			placemark.Polygon = &kmlPolygon{Coordinates: kmlCoordinates(feature.Polygon...)}
+		} else {
+			placemark.Point = &kmlPoint{Coordinates: kmlCoordinates(feature.Point)}
+		}
+
+		i, ok := folders[feature.Kind]
+		if !ok {
+			i = len(doc.Folders)
+			folders[feature.Kind] = i
+			doc.Styles = append(doc.Styles, kmlStyleFor(feature.Kind))
+			doc.Folders = append(doc.Folders, kmlFolder{Name: string(feature.Kind)})
+		}
+		doc.Folders[i].Placemarks = append(doc.Folders[i].Placemarks, placemark)
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
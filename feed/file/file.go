@@ -0,0 +1,297 @@
+// Package file writes feed entries as lines of JSON to files rotated by size and age
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// SyncPolicy decides when a file feed flushes its file to disk
+type SyncPolicy int
+
+const (
+	// SyncNone leaves flushing to the operating system
+	SyncNone SyncPolicy = iota
+	// SyncAlways flushes after every entry
+	SyncAlways
+	// SyncOnRotate flushes before a file is rotated or closed
+	SyncOnRotate
+)
+
+// Feed is a feed writing every entry as a line of JSON to a file, rotating the file by size and age
+//
+// Every line is appended with a single write, and a line left incomplete by a crash is cut off
+// when the file is opened again.
+type Feed struct {
+	// MaxSize is the size in bytes after which the file is rotated, zero means no limit
+	MaxSize int64
+	// MaxAge is how long a file is written to before it is rotated, zero means no limit
+	MaxAge time.Duration
+	// Compress gzips rotated files
+	Compress bool
+	// Sync decides when the file is flushed to disk
+	Sync SyncPolicy
+
+	path  string
+	clock api.Clock
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	err      error
+}
+
+// NewFeed constructs a feed appending to the file at the path, creating it if needed
+func NewFeed(path string) (*Feed, error) {
+	f := &Feed{
+		path:  path,
+		clock: &api.RealClock{},
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetClock sets the clock used to timestamp lines and to rotate by age
+func (f *Feed) SetClock(c api.Clock) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.clock = c
+	f.openedAt = c.Now()
+}
+
+func (f *Feed) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	size, err := repairLines(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = size
+	f.openedAt = f.clock.Now()
+	return nil
+}
+
+// repairLines cuts off an incomplete last line and returns the size of the file
+func repairLines(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+
+	r, err := os.Open(file.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		n, err := r.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+
+	if end == size {
+		return size, nil
+	}
+	return end, file.Truncate(end)
+}
+
+// Push writes a message without details of its call, or any other value that can be encoded as JSON
+func (f *Feed) Push(entry interface{}) {
+	switch e := entry.(type) {
+	case *api.FeedEntry:
+		f.PushEntry(e)
+	case proto.Message:
+		f.PushEntry(&api.FeedEntry{Message: e})
+	default:
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		b, err := api.MarshalEvent(entry, f.clock.Now())
+		if err == nil {
+			err = f.write(append(b, '\n'))
+		}
+		if err != nil {
+			f.err = err
+		}
+	}
+}
+
+// PushEntry writes an entry as a line of JSON, rotating the file first when it is due
+func (f *Feed) PushEntry(entry *api.FeedEntry) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	line, err := f.line(entry)
+	if err == nil {
+		err = f.write(line)
+	}
+	if err != nil {
+		f.err = err
+	}
+}
+
+func (f *Feed) line(entry *api.FeedEntry) ([]byte, error) {
+	b, err := api.MarshalEntry(entry, f.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func (f *Feed) write(line []byte) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	due := f.MaxAge > 0 && f.clock.Now().Sub(f.openedAt) >= f.MaxAge
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(len(line)) > f.MaxSize {
+		due = true
+	}
+	if due {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if f.Sync == SyncAlways {
+		return f.file.Sync()
+	}
+	return nil
+}
+
+// rotate moves the current file aside under a timestamped name and starts a new one
+func (f *Feed) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext)
+	stamp := f.clock.Now().UTC().Format("20060102T150405.000")
+	rotated := fmt.Sprintf("%s-%s%s", base, stamp, ext)
+	for i := 1; fileExists(rotated) || fileExists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%s.%d%s", base, stamp, i, ext)
+	}
+
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+	if f.Compress {
+		if err := gzipFile(rotated); err != nil {
+			return err
+		}
+	}
+	return f.open()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// gzipFile replaces a file with a gzipped copy named after it with a .gz extension
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(out)
+	_, err = io.Copy(w, in)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (f *Feed) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+	var err error
+	if f.Sync != SyncNone {
+		err = f.file.Sync()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file = nil
+	return err
+}
+
+// Err returns the last error that happened while writing, as pushing entries cannot return errors
+func (f *Feed) Err() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.err
+}
+
+// Close flushes and closes the file, a later push opens it again
+func (f *Feed) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.closeFile()
+}
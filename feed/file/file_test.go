@@ -0,0 +1,185 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// testClock is a clock that only moves when it is told to
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+type testEvent struct {
+	Name string
+}
+
+func (e *testEvent) Kind() string { return "test_event" }
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %s", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestFeedLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filefeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "feed.jsonl")
+
+	// A line torn by a crash is cut off when the file is opened
+	if err := ioutil.WriteFile(path, []byte("{\"type\":\"complete\"}\n{\"type\":\"tor"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := NewFeed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.Sync = SyncAlways
+	feed.PushEntry(&api.FeedEntry{
+		Timestamp:   time.Now(),
+		AccountID:   "ptc:ash",
+		Location:    api.Location{Lat: 52.52, Lon: 13.404},
+		RequestType: protos.RequestType_GET_INVENTORY,
+		Latency:     150 * time.Millisecond,
+		Message:     &protos.GetInventoryResponse{Success: true, InventoryDelta: &protos.InventoryDelta{NewTimestampMs: 1470000000000}},
+	})
+	feed.Push(&protos.GetPlayerResponse{})
+	feed.Push(&testEvent{Name: "appeared"})
+	if err := feed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Err() != nil {
+		t.Fatal(feed.Err())
+	}
+
+	lines := readLines(t, path)
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(lines))
+	}
+	line := lines[1]
+	if line["account_id"] != "ptc:ash" || line["request_type"] != "GET_INVENTORY" || line["latency_ms"] != 150.0 {
+		t.Errorf("expected the details of the call, got %v", line)
+	}
+	location := line["location"].(map[string]interface{})
+	if location["lat"] != 52.52 || location["lon"] != 13.404 {
+		t.Errorf("expected the location with snake_case keys, got %v", location)
+	}
+	// jsonpb writes 64 bit integers as strings so they keep their precision
+	delta := line["message"].(map[string]interface{})["inventoryDelta"].(map[string]interface{})
+	if delta["newTimestampMs"] != "1470000000000" {
+		t.Errorf("expected the timestamp as a string, got %v", delta["newTimestampMs"])
+	}
+	if _, ok := lines[2]["request_type"]; ok || lines[2]["timestamp"] == nil {
+		t.Errorf("expected a timestamp but no call details for a plain message, got %v", lines[2])
+	}
+	if lines[3]["type"] != "test_event" || lines[3]["event"].(map[string]interface{})["Name"] != "appeared" {
+		t.Errorf("expected the event typed by its kind, got %v", lines[3])
+	}
+}
+
+func TestFeedRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filefeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "feed.jsonl")
+
+	feed, err := NewFeed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &testClock{now: time.Date(2016, 8, 1, 12, 0, 0, 0, time.UTC)}
+	feed.SetClock(clock)
+	feed.MaxAge = time.Hour
+	feed.Compress = true
+
+	feed.Push(&protos.GetPlayerResponse{})
+	feed.Push(&protos.GetPlayerResponse{})
+	clock.now = clock.now.Add(time.Hour)
+	feed.Push(&protos.GetPlayerResponse{})
+	if err := feed.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := filepath.Join(dir, "feed-20160801T130000.000.jsonl.gz")
+	f, err := os.Open(rotated)
+	if err != nil {
+		t.Fatalf("expected a compressed rotated file: %s", err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(r)
+	if countLines(b) != 2 {
+		t.Errorf("expected 2 lines in the rotated file, got %d", countLines(b))
+	}
+	if lines := readLines(t, path); len(lines) != 1 {
+		t.Errorf("expected 1 line in the new file, got %d", len(lines))
+	}
+
+	// Rotating by size starts a new file once the next line does not fit
+	feed.MaxAge = 0
+	feed.Compress = false
+	feed.MaxSize = 1
+	feed.Push(&protos.GetPlayerResponse{})
+	feed.Push(&protos.GetPlayerResponse{})
+	if err := feed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "feed-*.jsonl"))
+	if len(files) != 2 {
+		t.Errorf("expected 2 more rotated files, got %v", files)
+	}
+}
+
+func countLines(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,328 @@
+// Package webhook posts feed entries in batches of JSON to webhooks
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// SignatureHeader is the header carrying the HMAC-SHA256 of the payload, as "sha256=" and the hex digest
+const SignatureHeader = "X-Pgoapi-Signature"
+
+type webhookPayload struct {
+	Entries []json.RawMessage `json:"entries"`
+}
+
+// persistedBatch is a batch that could not be delivered, kept on disk to be sent again later
+type persistedBatch struct {
+	URL  string          `json:"url"`
+	Body json.RawMessage `json:"body"`
+}
+
+// Feed is a feed posting batches of entries as JSON to one or more URLs
+//
+// A batch is posted once BatchSize entries are waiting or BatchInterval has passed. Failed posts are retried
+// with an exponential backoff and batches that still cannot be delivered are written to Dir, from where they
+// are sent again every BatchInterval. Values pushed that are not messages, like map events, are posted with
+// their kind as type.
+type Feed struct {
+	URLs []string
+	// Secret signs every payload in the SignatureHeader, no header is sent when it is empty
+	Secret        []byte
+	BatchSize     int
+	BatchInterval time.Duration
+	// MaxRetries is how many times a failed post is retried, waiting Backoff and then twice as long each time
+	MaxRetries int
+	Backoff    time.Duration
+	// Dir is where undelivered batches are kept, they are dropped when it is empty
+	Dir    string
+	Client *http.Client
+
+	mutex   sync.Mutex
+	pending []json.RawMessage
+	closed  bool
+	err     error
+
+	once  sync.Once
+	flush chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+
+	// ctx is cancelled when the feed is closed, aborting the posts in flight,
+	// while the last batch is posted with the context given to Close
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closeCtx context.Context
+}
+
+// NewFeed constructs a feed posting to the URLs in batches of up to 100 entries every 5 seconds
+func NewFeed(urls ...string) *Feed {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Feed{
+		URLs:          urls,
+		BatchSize:     100,
+		BatchInterval: 5 * time.Second,
+		MaxRetries:    5,
+		Backoff:       time.Second,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		flush:         make(chan struct{}, 1),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Push queues a message, or any other value that can be encoded as JSON
+func (f *Feed) Push(entry interface{}) {
+	switch e := entry.(type) {
+	case *api.FeedEntry:
+		f.PushEntry(e)
+	case proto.Message:
+		f.PushEntry(&api.FeedEntry{Message: e})
+	default:
+		f.queue(api.MarshalEvent(entry, time.Now()))
+	}
+}
+
+// PushEntry queues an entry
+func (f *Feed) PushEntry(entry *api.FeedEntry) {
+	f.queue(api.MarshalEntry(entry, time.Now()))
+}
+
+func (f *Feed) queue(b []byte, err error) {
+	f.once.Do(func() {
+		go f.run()
+	})
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err != nil {
+		f.err = err
+		return
+	}
+	if f.closed {
+		return
+	}
+	f.pending = append(f.pending, json.RawMessage(b))
+	if len(f.pending) >= f.BatchSize {
+		select {
+		case f.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *Feed) take() []json.RawMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	batch := f.pending
+	f.pending = nil
+	return batch
+}
+
+func (f *Feed) run() {
+	defer close(f.done)
+
+	// A ticker keeps the kept batches being resent while full batches are flushed in between
+	ticker := time.NewTicker(f.BatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.flush:
+		case <-ticker.C:
+			f.resend(f.ctx)
+		case <-f.quit:
+			f.mutex.Lock()
+			ctx := f.closeCtx
+			f.mutex.Unlock()
+			f.send(ctx, f.take())
+			return
+		}
+		f.send(f.ctx, f.take())
+	}
+}
+
+func (f *Feed) setErr(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.err = err
+}
+
+// send posts a batch to every URL, keeping it on disk for the URLs it could not be delivered to
+func (f *Feed) send(ctx context.Context, batch []json.RawMessage) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(webhookPayload{Entries: batch})
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+
+	for _, url := range f.URLs {
+		err := f.post(ctx, url, body)
+		for retry, backoff := 0, f.Backoff; err != nil && retry < f.MaxRetries && f.wait(backoff); retry, backoff = retry+1, backoff*2 {
+			err = f.post(ctx, url, body)
+		}
+		if err != nil {
+			f.setErr(err)
+			if err := f.persist(url, body); err != nil {
+				f.setErr(err)
+			}
+		}
+	}
+}
+
+// wait sleeps before a retry, returning false without waiting any longer once the feed is closed
+func (f *Feed) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-f.quit:
+		return false
+	}
+}
+
+func (f *Feed) post(ctx context.Context, url string, body []byte) error {
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(f.Secret) > 0 {
+		mac := hmac.New(sha256.New, f.Secret)
+		mac.Write(body)
+		request.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := ctxhttp.Do(ctx, f.Client, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	ioutil.ReadAll(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Webhook %s responded with status %d", url, response.StatusCode)
+	}
+	return nil
+}
+
+func (f *Feed) persist(url string, body []byte) error {
+	if f.Dir == "" {
+		return nil
+	}
+	b, err := json.Marshal(persistedBatch{URL: url, Body: body})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(f.Dir, fmt.Sprintf("batch-%d.json", time.Now().UnixNano()))
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// resend posts the batches kept on disk once more, oldest first
+func (f *Feed) resend(ctx context.Context) {
+	if f.Dir == "" {
+		return
+	}
+	files, err := ioutil.ReadDir(f.Dir)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "batch-") && strings.HasSuffix(file.Name(), ".json") {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(f.Dir, name)
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.setErr(err)
+			continue
+		}
+		var batch persistedBatch
+		if err := json.Unmarshal(b, &batch); err != nil {
+			f.setErr(err)
+			continue
+		}
+		if err := f.post(ctx, batch.URL, batch.Body); err != nil {
+			f.setErr(err)
+			continue
+		}
+		os.Remove(path)
+	}
+}
+
+// Err returns the last error that happened while encoding or delivering entries
+func (f *Feed) Err() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.err
+}
+
+// Close stops accepting entries and waits until the queued entries are delivered or kept on disk,
+// returning early when the context is done. Posts in flight are aborted and failed posts are no longer retried
+// once the feed is closed, the entries still queued are posted for as long as the context allows.
+func (f *Feed) Close(ctx context.Context) error {
+	f.once.Do(func() {
+		close(f.done)
+	})
+
+	f.mutex.Lock()
+	if !f.closed {
+		f.closed = true
+		f.closeCtx = ctx
+		close(f.quit)
+		f.cancel()
+	}
+	f.mutex.Unlock()
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,269 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// webhookReceiver records the payloads it accepts and fails the requests it is told to
+type webhookReceiver struct {
+	*httptest.Server
+	t        *testing.T
+	mutex    sync.Mutex
+	failures int
+	payloads []webhookPayload
+	received chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, failures int) *webhookReceiver {
+	r := &webhookReceiver{t: t, failures: failures, received: make(chan struct{}, 10)}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+func (r *webhookReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if req.Header.Get(SignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		r.t.Errorf("invalid signature %q", req.Header.Get(SignatureHeader))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		r.t.Errorf("invalid payload: %s", err)
+	}
+	r.payloads = append(r.payloads, payload)
+	r.received <- struct{}{}
+}
+
+func (r *webhookReceiver) wait(t *testing.T) {
+	select {
+	case <-r.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a payload")
+	}
+}
+
+type testEvent struct {
+	Name string
+}
+
+func (e *testEvent) Kind() string { return "test_event" }
+
+func newTestFeed(urls ...string) *Feed {
+	feed := NewFeed(urls...)
+	feed.Secret = []byte("secret")
+	feed.BatchSize = 2
+	feed.BatchInterval = time.Hour
+	feed.Backoff = time.Millisecond
+	return feed
+}
+
+func TestFeedBatches(t *testing.T) {
+	receiver := newWebhookReceiver(t, 2)
+	defer receiver.Close()
+
+	feed := newTestFeed(receiver.URL)
+	feed.PushEntry(&api.FeedEntry{Timestamp: time.Now(), AccountID: "ptc:ash", Message: &protos.GetPlayerResponse{}})
+	feed.Push(&testEvent{Name: "pidgey"})
+	receiver.wait(t)
+
+	// Entries left over are sent when the feed is closed
+	feed.Push(&protos.GetPlayerResponse{})
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	receiver.wait(t)
+
+	if len(receiver.payloads) != 2 || len(receiver.payloads[0].Entries) != 2 || len(receiver.payloads[1].Entries) != 1 {
+		t.Fatalf("expected a batch of 2 and a batch of 1 entries, got %v", receiver.payloads)
+	}
+	var event struct {
+		Type string
+	}
+	json.Unmarshal(receiver.payloads[0].Entries[1], &event)
+	if event.Type != "test_event" {
+		t.Errorf("expected the event with its kind, got %v", event)
+	}
+}
+
+func TestFeedPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhookfeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	receiver := newWebhookReceiver(t, 3)
+	defer receiver.Close()
+
+	// The directory for undelivered batches is created once it is needed
+	dir = filepath.Join(dir, "undelivered")
+	feed := newTestFeed(receiver.URL)
+	feed.MaxRetries = 2
+	feed.Dir = dir
+	feed.Push(&protos.GetPlayerResponse{})
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Err() == nil {
+		t.Errorf("expected the failed delivery to be reported")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected the undelivered batch to be kept, got %v", files)
+	}
+
+	// A new feed sends the kept batches again
+	feed = newTestFeed(receiver.URL)
+	feed.Dir = dir
+	feed.BatchInterval = 10 * time.Millisecond
+	feed.Push(&protos.GetPlayerResponse{})
+	receiver.wait(t)
+
+	// Closing aborts the posts in flight, so the kept batch is only known to be delivered once it is removed
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if files, _ = filepath.Glob(filepath.Join(dir, "batch-*.json")); len(files) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the kept batch to be removed once delivered, got %v", files)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFeedCloseStopsRetries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhookfeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	receiver := newWebhookReceiver(t, 100)
+	defer receiver.Close()
+
+	feed := newTestFeed(receiver.URL)
+	feed.BatchSize = 1
+	feed.Backoff = time.Hour
+	feed.Dir = dir
+	feed.Push(&protos.GetPlayerResponse{})
+
+	// Give the worker time to fail the first post and start waiting for the retry
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := feed.Close(ctx); err != nil {
+		t.Fatalf("expected closing to stop the retries, got %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json"))
+	if len(files) != 1 {
+		t.Errorf("expected the undelivered batch to be kept, got %v", files)
+	}
+}
+
+func TestFeedCloseAbortsPosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhookfeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The receiver never answers until the test is over
+	posted := make(chan struct{}, 1)
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		posted <- struct{}{}
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	feed := newTestFeed(receiver.URL)
+	feed.BatchSize = 1
+	feed.Client = &http.Client{}
+	feed.Dir = dir
+	feed.Push(&protos.GetPlayerResponse{})
+	<-posted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := feed.Close(ctx); err != nil {
+		t.Fatalf("expected closing to abort the post, got %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json"))
+	if len(files) != 1 {
+		t.Errorf("expected the aborted batch to be kept, got %v", files)
+	}
+}
+
+func TestFeedResendsWhileBusy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhookfeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	receiver := newWebhookReceiver(t, 0)
+	defer receiver.Close()
+	receiver.received = make(chan struct{}, 1000)
+
+	kept, _ := json.Marshal(persistedBatch{URL: receiver.URL, Body: json.RawMessage(`{"entries":[]}`)})
+	if err := ioutil.WriteFile(filepath.Join(dir, "batch-1.json"), kept, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	feed := newTestFeed(receiver.URL)
+	feed.BatchSize = 1
+	feed.BatchInterval = 20 * time.Millisecond
+	feed.Dir = dir
+
+	// Full batches keep arriving more often than the interval
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		feed.Push(&protos.GetPlayerResponse{})
+		if files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json")); len(files) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the kept batch to be resent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := feed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+package gamemaster
+
+import (
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+const defaultAppVersion = 4500
+
+// Cache keeps the item templates in a file and only downloads them again when the remote service has newer ones
+type Cache struct {
+	Path       string
+	Platform   protos.Platform
+	AppVersion uint32
+}
+
+// NewCache constructs a cache storing the item templates at the given path
+func NewCache(path string) *Cache {
+	return &Cache{
+		Path:       path,
+		Platform:   protos.Platform_IOS,
+		AppVersion: defaultAppVersion,
+	}
+}
+
+// Get returns the cached item templates, downloading and storing them if the remote service has a newer version
+func (c *Cache) Get(ctx context.Context, session Client, proxyId int64) (*GameMaster, error) {
+	cached, err := Load(c.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	version, err := session.DownloadRemoteConfigVersion(ctx, c.Platform, c.AppVersion, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && cached.TimestampMs() >= version.ItemTemplatesTimestampMs {
+		return cached, nil
+	}
+
+	g, err := Download(ctx, session, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	err = Save(c.Path, g)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Load reads item templates previously stored with Save
+func Load(path string) (*GameMaster, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	response := &protos.DownloadItemTemplatesResponse{}
+	err = proto.Unmarshal(b, response)
+	if err != nil {
+		return nil, err
+	}
+	return New(response), nil
+}
+
+// Save stores the item templates along with their timestamp in a file
+func Save(path string, g *GameMaster) error {
+	b, err := proto.Marshal(g.response)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial cache behind
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
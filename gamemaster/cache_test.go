@@ -0,0 +1,87 @@
+package gamemaster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// testClient serves the test templates and counts the downloads
+type testClient struct {
+	timestampMs uint64
+	downloads   int
+}
+
+func (c *testClient) DownloadRemoteConfigVersion(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.DownloadRemoteConfigVersionResponse, error) {
+	return &protos.DownloadRemoteConfigVersionResponse{
+		Result:                   protos.DownloadRemoteConfigVersionResponse_SUCCESS,
+		ItemTemplatesTimestampMs: c.timestampMs,
+	}, nil
+}
+
+func (c *testClient) DownloadItemTemplates(ctx context.Context, proxyId int64) (*protos.DownloadItemTemplatesResponse, error) {
+	c.downloads++
+	response := proto.Clone(testTemplates).(*protos.DownloadItemTemplatesResponse)
+	response.TimestampMs = c.timestampMs
+	return response, nil
+}
+
+func TestCacheGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gamemaster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCache(filepath.Join(dir, "templates.bin"))
+	client := &testClient{timestampMs: testTemplates.TimestampMs}
+	ctx := context.Background()
+
+	// Nothing is cached yet
+	g, err := cache.Get(ctx, client, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.downloads != 1 || g.TimestampMs() != testTemplates.TimestampMs {
+		t.Fatalf("expected the templates to be downloaded, got %d downloads", client.downloads)
+	}
+
+	// The cached templates are as new as the remote ones
+	if g, err = cache.Get(ctx, client, -1); err != nil {
+		t.Fatal(err)
+	}
+	if client.downloads != 1 {
+		t.Errorf("expected the cached templates without a download, got %d downloads", client.downloads)
+	}
+	if _, ok := g.Pokemon(protos.PokemonId_PIKACHU); !ok {
+		t.Error("expected the cached templates to be indexed")
+	}
+
+	// The remote service has newer templates
+	client.timestampMs++
+	if g, err = cache.Get(ctx, client, -1); err != nil {
+		t.Fatal(err)
+	}
+	if client.downloads != 2 || g.TimestampMs() != client.timestampMs {
+		t.Errorf("expected a stale cache to be downloaded again, got %d downloads at %d", client.downloads, g.TimestampMs())
+	}
+	if saved, err := Load(cache.Path); err != nil || saved.TimestampMs() != client.timestampMs {
+		t.Errorf("expected the new templates to be saved, got %v", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	client := &testClient{timestampMs: 42}
+	g, err := Download(context.Background(), client, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TimestampMs() != 42 || len(g.Templates()) != len(testTemplates.ItemTemplates) {
+		t.Errorf("unexpected templates %d at %d", len(g.Templates()), g.TimestampMs())
+	}
+}
@@ -0,0 +1,111 @@
+// Package gamemaster provides typed lookups in the item templates of the Pokémon Go game master
+package gamemaster
+
+import (
+	"golang.org/x/net/context"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// GameMaster contains the item templates with base stats, moves, items and player levels
+type GameMaster struct {
+	response *protos.DownloadItemTemplatesResponse
+
+	pokemon     map[protos.PokemonId]*protos.PokemonSettings
+	moves       map[protos.PokemonMove]*protos.MoveSettings
+	items       map[protos.ItemId]*protos.ItemSettings
+	playerLevel *protos.PlayerLevelSettings
+}
+
+// New indexes the item templates from a download response
+func New(response *protos.DownloadItemTemplatesResponse) *GameMaster {
+	g := &GameMaster{
+		response: response,
+		pokemon:  make(map[protos.PokemonId]*protos.PokemonSettings),
+		moves:    make(map[protos.PokemonMove]*protos.MoveSettings),
+		items:    make(map[protos.ItemId]*protos.ItemSettings),
+	}
+
+	for _, template := range response.GetItemTemplates() {
+		if settings := template.GetPokemonSettings(); settings != nil {
+			g.pokemon[settings.PokemonId] = settings
+		}
+		if settings := template.GetMoveSettings(); settings != nil {
+			g.moves[settings.MovementId] = settings
+		}
+		if settings := template.GetItemSettings(); settings != nil {
+			g.items[settings.ItemId] = settings
+		}
+		if settings := template.GetPlayerLevel(); settings != nil {
+			g.playerLevel = settings
+		}
+	}
+
+	return g
+}
+
+// Client is the part of api.Session used to retrieve the item templates
+type Client interface {
+	DownloadRemoteConfigVersion(ctx context.Context, platform protos.Platform, appVersion uint32, proxyId int64) (*protos.DownloadRemoteConfigVersionResponse, error)
+	DownloadItemTemplates(ctx context.Context, proxyId int64) (*protos.DownloadItemTemplatesResponse, error)
+}
+
+// Download retrieves the item templates through the session
+func Download(ctx context.Context, session Client, proxyId int64) (*GameMaster, error) {
+	response, err := session.DownloadItemTemplates(ctx, proxyId)
+	if err != nil {
+		return nil, err
+	}
+	return New(response), nil
+}
+
+// TimestampMs returns the time the item templates were last changed by the remote service
+func (g *GameMaster) TimestampMs() uint64 {
+	return g.response.GetTimestampMs()
+}
+
+// Templates returns all the item templates
+func (g *GameMaster) Templates() []*protos.DownloadItemTemplatesResponse_ItemTemplate {
+	return g.response.GetItemTemplates()
+}
+
+// Pokemon returns the settings, like base stats and evolutions, for a Pokémon
+func (g *GameMaster) Pokemon(id protos.PokemonId) (*protos.PokemonSettings, bool) {
+	settings, ok := g.pokemon[id]
+	return settings, ok
+}
+
+// Move returns the settings, like power and duration, for a move
+func (g *GameMaster) Move(id protos.PokemonMove) (*protos.MoveSettings, bool) {
+	settings, ok := g.moves[id]
+	return settings, ok
+}
+
+// Item returns the settings for an item
+func (g *GameMaster) Item(id protos.ItemId) (*protos.ItemSettings, bool) {
+	settings, ok := g.items[id]
+	return settings, ok
+}
+
+// MaxLevel returns the highest player level known to the game master
+func (g *GameMaster) MaxLevel() int {
+	return len(g.playerLevel.GetCpMultiplier())
+}
+
+// CpMultiplier returns the combat power multiplier for a Pokémon or player level, starting at level 1
+func (g *GameMaster) CpMultiplier(level int) (float32, bool) {
+	multipliers := g.playerLevel.GetCpMultiplier()
+	if level < 1 || level > len(multipliers) {
+		return 0, false
+	}
+	return multipliers[level-1], true
+}
+
+// RequiredExperience returns the total experience needed to reach a player level, starting at level 1
+func (g *GameMaster) RequiredExperience(level int) (int32, bool) {
+	experience := g.playerLevel.GetRequiredExperience()
+	if level < 1 || level > len(experience) {
+		return 0, false
+	}
+	return experience[level-1], true
+}
@@ -0,0 +1,94 @@
+package gamemaster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+var testTemplates = &protos.DownloadItemTemplatesResponse{
+	Success:     true,
+	TimestampMs: 1478000000000,
+	ItemTemplates: []*protos.DownloadItemTemplatesResponse_ItemTemplate{
+		{
+			TemplateId: "V0025_POKEMON_PIKACHU",
+			PokemonSettings: &protos.PokemonSettings{
+				PokemonId:     protos.PokemonId_PIKACHU,
+				Stats:         &protos.StatsAttributes{BaseStamina: 70, BaseAttack: 124, BaseDefense: 108},
+				CandyToEvolve: 50,
+			},
+		},
+		{
+			TemplateId:   "V0221_MOVE_TACKLE_FAST",
+			MoveSettings: &protos.MoveSettings{MovementId: protos.PokemonMove_TACKLE_FAST, Power: 12, DurationMs: 1100},
+		},
+		{
+			TemplateId:   "ITEM_POTION",
+			ItemSettings: &protos.ItemSettings{ItemId: protos.ItemId_ITEM_POTION, ItemType: protos.ItemType_POTION},
+		},
+		{
+			TemplateId: "PLAYER_LEVEL_SETTINGS",
+			PlayerLevel: &protos.PlayerLevelSettings{
+				RequiredExperience: []int32{0, 1000, 3000},
+				CpMultiplier:       []float32{0.094, 0.16639787, 0.21573247},
+			},
+		},
+	},
+}
+
+func TestLookup(t *testing.T) {
+	g := New(testTemplates)
+
+	pokemon, ok := g.Pokemon(protos.PokemonId_PIKACHU)
+	if !ok || pokemon.Stats.BaseAttack != 124 || pokemon.CandyToEvolve != 50 {
+		t.Errorf("unexpected Pokémon settings %v", pokemon)
+	}
+	if _, ok := g.Pokemon(protos.PokemonId_MEW); ok {
+		t.Error("expected no settings for unknown Pokémon")
+	}
+	if move, ok := g.Move(protos.PokemonMove_TACKLE_FAST); !ok || move.DurationMs != 1100 {
+		t.Errorf("unexpected move settings %v", move)
+	}
+	if item, ok := g.Item(protos.ItemId_ITEM_POTION); !ok || item.ItemType != protos.ItemType_POTION {
+		t.Errorf("unexpected item settings %v", item)
+	}
+
+	if g.MaxLevel() != 3 {
+		t.Errorf("expected max level 3, got %d", g.MaxLevel())
+	}
+	if multiplier, ok := g.CpMultiplier(2); !ok || multiplier != 0.16639787 {
+		t.Errorf("unexpected cp multiplier %f", multiplier)
+	}
+	if _, ok := g.CpMultiplier(4); ok {
+		t.Error("expected no cp multiplier above max level")
+	}
+	if experience, ok := g.RequiredExperience(3); !ok || experience != 3000 {
+		t.Errorf("unexpected required experience %d", experience)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gamemaster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "templates.bin")
+
+	if err := Save(path, New(testTemplates)); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TimestampMs() != testTemplates.TimestampMs || len(g.Templates()) != len(testTemplates.ItemTemplates) {
+		t.Errorf("unexpected templates after load: %d at %d", len(g.Templates()), g.TimestampMs())
+	}
+	if _, ok := g.Pokemon(protos.PokemonId_PIKACHU); !ok {
+		t.Error("expected loaded templates to be indexed")
+	}
+}
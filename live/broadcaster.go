@@ -0,0 +1,232 @@
+// Package live streams feed entries and map events to browsers over WebSocket and Server-Sent Events
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/events"
+)
+
+// point is a coordinate a message is about, used to filter messages by bounding box
+type point struct {
+	lat float64
+	lon float64
+}
+
+func (p point) GetLatitude() float64  { return p.lat }
+func (p point) GetLongitude() float64 { return p.lon }
+
+// message is an entry or event encoded once for all clients
+type message struct {
+	kind   string
+	points []point
+	data   []byte
+}
+
+type envelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Filter decides which messages a client receives
+type Filter struct {
+	// Box limits messages to those about a location within it, unless it is nil
+	Box *api.BoundingBox
+	// Types limits messages to those of the types, which may be given without the proto package, unless it is empty
+	Types []string
+}
+
+func (f *Filter) matches(m *message) bool {
+	if len(f.Types) > 0 {
+		matched := false
+		for _, t := range f.Types {
+			if m.kind == t || strings.HasSuffix(m.kind, "."+t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	// Messages without a location are only sent to clients without a box
+	if f.Box == nil {
+		return true
+	}
+	for _, p := range m.points {
+		if f.Box.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+type client struct {
+	filter   Filter
+	messages chan []byte
+	dropped  uint64
+}
+
+// Broadcaster is a feed and map event handler passing everything on to the connected clients
+//
+// Every client has its own buffer, messages for a client whose buffer is full are dropped
+// so that a slow client never holds up the session.
+type Broadcaster struct {
+	// BufferSize is how many messages are buffered for every client connecting from now on
+	BufferSize int
+
+	mutex   sync.RWMutex
+	clients map[*client]bool
+	dropped uint64
+}
+
+// NewBroadcaster constructs a broadcaster buffering up to 64 messages per client
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		BufferSize: 64,
+		clients:    make(map[*client]bool),
+	}
+}
+
+func (b *Broadcaster) subscribe(filter Filter) *client {
+	c := &client{
+		filter:   filter,
+		messages: make(chan []byte, b.BufferSize),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.clients[c] = true
+	return c
+}
+
+func (b *Broadcaster) unsubscribe(c *client) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.clients, c)
+}
+
+// Clients returns the number of connected clients
+func (b *Broadcaster) Clients() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return len(b.clients)
+}
+
+// Dropped returns the number of messages dropped because a client was too slow
+func (b *Broadcaster) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+func (b *Broadcaster) broadcast(kind string, points []point, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	encoded, err := json.Marshal(envelope{Type: kind, Data: raw})
+	if err != nil {
+		return
+	}
+	m := &message{kind: kind, points: points, data: encoded}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for c := range b.clients {
+		if !c.filter.matches(m) {
+			continue
+		}
+		select {
+		case c.messages <- m.data:
+		default:
+			atomic.AddUint64(&c.dropped, 1)
+			atomic.AddUint64(&b.dropped, 1)
+		}
+	}
+}
+
+// Push passes a message on to the clients
+func (b *Broadcaster) Push(entry interface{}) {
+	if message, ok := entry.(proto.Message); ok {
+		b.PushEntry(&api.FeedEntry{Message: message})
+	}
+}
+
+// PushEntry passes an entry on to the clients, it is about the location of the player and of every map object in it
+func (b *Broadcaster) PushEntry(entry *api.FeedEntry) {
+	points := make([]point, 0)
+	if entry.Location.Lat != 0 || entry.Location.Lon != 0 {
+		points = append(points, point{entry.Location.Lat, entry.Location.Lon})
+	}
+	if mapObjects, ok := entry.Message.(*protos.GetMapObjectsResponse); ok {
+		for _, cell := range mapObjects.GetMapCells() {
+			for _, fort := range cell.Forts {
+				points = append(points, point{fort.Latitude, fort.Longitude})
+			}
+			for _, pokemon := range cell.WildPokemons {
+				points = append(points, point{pokemon.Latitude, pokemon.Longitude})
+			}
+		}
+	}
+
+	b.broadcast(proto.MessageName(entry.Message), points, entry)
+}
+
+// Handle passes a map event on to the clients
+func (b *Broadcaster) Handle(event events.Event) {
+	b.broadcast(event.Kind(), eventPoints(event), event)
+}
+
+func eventPoints(event events.Event) []point {
+	var fort *protos.FortData
+	switch e := event.(type) {
+	case *events.PokemonAppeared:
+		return []point{{e.Pokemon.Latitude, e.Pokemon.Longitude}}
+	case *events.PokemonDespawned:
+		return []point{{e.Pokemon.Latitude, e.Pokemon.Longitude}}
+	case *events.FortAdded:
+		fort = e.Fort
+	case *events.FortRemoved:
+		fort = e.Fort
+	case *events.FortLured:
+		fort = e.Fort
+	case *events.LureExpired:
+		fort = e.Fort
+	case *events.GymTeamChanged:
+		fort = e.Fort
+	case *events.GymPrestigeChanged:
+		fort = e.Fort
+	default:
+		return nil
+	}
+	return []point{{fort.Latitude, fort.Longitude}}
+}
+
+// ParseFilter reads a filter from the "box" and "types" query parameters,
+// where the box is given as "minLat,minLon,maxLat,maxLon" and the types are separated by commas
+func ParseFilter(box string, types string) (Filter, error) {
+	var f Filter
+	if box != "" {
+		var b api.BoundingBox
+		if _, err := fmt.Sscanf(box, "%g,%g,%g,%g", &b.MinLat, &b.MinLon, &b.MaxLat, &b.MaxLon); err != nil {
+			return f, fmt.Errorf("Invalid box %q: %s", box, err)
+		}
+		f.Box = &b
+	}
+	if types != "" {
+		f.Types = strings.Split(types, ",")
+	}
+	return f, nil
+}
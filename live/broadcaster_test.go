@@ -0,0 +1,150 @@
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+	"golang.org/x/net/websocket"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/events"
+)
+
+func waitForClients(t *testing.T, b *Broadcaster, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Clients() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d clients, got %d", n, b.Clients())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func readEnvelope(t *testing.T, data string) envelope {
+	var e envelope
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestFilter(t *testing.T) {
+	f, err := ParseFilter("59,18,60,19", "pokemon_appeared,GetMapObjectsResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := map[*message]bool{
+		{kind: "pokemon_appeared", points: []point{{59.5, 18.5}}}:                                                true,
+		{kind: "pokemon_appeared", points: []point{{10, 10}}}:                                                    false,
+		{kind: "fort_added", points: []point{{59.5, 18.5}}}:                                                      false,
+		{kind: "POGOProtos.Networking.Responses.GetMapObjectsResponse", points: []point{{10, 10}, {59.1, 18.1}}}: true,
+		{kind: "POGOProtos.Networking.Responses.GetMapObjectsResponse"}:                                          false,
+	}
+	for m, expected := range messages {
+		if f.matches(m) != expected {
+			t.Errorf("expected %s at %v to match %v", m.kind, m.points, expected)
+		}
+	}
+
+	if _, err := ParseFilter("59,18", ""); err == nil {
+		t.Errorf("expected an incomplete box to be rejected")
+	}
+}
+
+func TestSSE(t *testing.T) {
+	b := NewBroadcaster()
+	server := httptest.NewServer(b.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?box=59,18,60,19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+	waitForClients(t, b, 1)
+
+	b.Handle(&events.PokemonAppeared{Pokemon: &protos.WildPokemon{EncounterId: 1, Latitude: 10, Longitude: 10}})
+	b.Handle(&events.PokemonAppeared{Pokemon: &protos.WildPokemon{EncounterId: 2, Latitude: 59.5, Longitude: 18.5}})
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "data: ") {
+		t.Fatalf("unexpected line %q", line)
+	}
+	e := readEnvelope(t, strings.TrimPrefix(line, "data: "))
+	if e.Type != "pokemon_appeared" || !strings.Contains(string(e.Data), `"encounter_id":2`) {
+		t.Errorf("expected only the Pokémon inside the box, got %s %s", e.Type, e.Data)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	b := NewBroadcaster()
+	server := httptest.NewServer(b.Handler())
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?types=GetMapObjectsResponse"
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForClients(t, b, 1)
+
+	b.Handle(&events.FortAdded{Fort: &protos.FortData{Id: "fort"}})
+	b.Push(&protos.GetMapObjectsResponse{Status: protos.MapObjectsStatus_SUCCESS})
+
+	var data string
+	if err := websocket.Message.Receive(ws, &data); err != nil {
+		t.Fatal(err)
+	}
+	if e := readEnvelope(t, data); !strings.HasSuffix(e.Type, ".GetMapObjectsResponse") {
+		t.Errorf("expected only the map objects, got %s", e.Type)
+	}
+
+	ws.Close()
+	waitForClients(t, b, 0)
+
+	if _, err := websocket.Dial(url, "", "http://example.com"); err == nil {
+		t.Error("expected a page of another site to be refused")
+	}
+}
+
+func TestEntryLocation(t *testing.T) {
+	b := NewBroadcaster()
+	f, err := ParseFilter("59,18,60,19", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := b.subscribe(f)
+
+	// Entries are placed where the player was, whether or not they have a timestamp
+	b.PushEntry(&api.FeedEntry{Location: api.Location{Lat: 59.5, Lon: 18.5}, Message: &protos.GetPlayerResponse{}})
+	b.PushEntry(&api.FeedEntry{Timestamp: time.Now(), Message: &protos.GetPlayerResponse{}})
+	if len(c.messages) != 1 {
+		t.Errorf("expected only the entry with a location inside the box, got %d", len(c.messages))
+	}
+}
+
+func TestSlowClient(t *testing.T) {
+	b := NewBroadcaster()
+	b.BufferSize = 2
+	c := b.subscribe(Filter{})
+
+	for i := 0; i < 5; i++ {
+		b.Handle(&events.FortAdded{Fort: &protos.FortData{Id: "fort"}})
+	}
+	if len(c.messages) != 2 || b.Dropped() != 3 {
+		t.Errorf("expected 2 buffered and 3 dropped messages, got %d and %d", len(c.messages), b.Dropped())
+	}
+}
@@ -0,0 +1,112 @@
+package live
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+)
+
+func (b *Broadcaster) filter(w http.ResponseWriter, r *http.Request) (Filter, bool) {
+	query := r.URL.Query()
+	f, err := ParseFilter(query.Get("box"), query.Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return f, false
+	}
+	return f, true
+}
+
+// Handler returns a handler serving Server-Sent Events on /events and WebSocket on /ws
+func (b *Broadcaster) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/events", b.SSEHandler())
+	mux.Handle("/ws", b.WebSocketHandler())
+	return mux
+}
+
+// SSEHandler returns a handler streaming messages as Server-Sent Events
+func (b *Broadcaster) SSEHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+		f, ok := b.filter(w, r)
+		if !ok {
+			return
+		}
+
+		c := b.subscribe(f)
+		defer b.unsubscribe(c)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data := <-c.messages:
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// sameOrigin accepts WebSocket connections from pages served by the same host only,
+// so that other sites cannot read the feed through the browser of a visitor
+func sameOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != r.Host {
+		return fmt.Errorf("Origin %v is not allowed", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+// WebSocketHandler returns a handler sending every message as a WebSocket text frame,
+// to pages served by the same host only
+func (b *Broadcaster) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := b.filter(w, r)
+		if !ok {
+			return
+		}
+
+		handler := func(ws *websocket.Conn) {
+			c := b.subscribe(f)
+			defer b.unsubscribe(c)
+
+			// Clients do not send anything, so reading only tells when the connection is closed
+			closed := make(chan struct{})
+			go func() {
+				var discard string
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+				close(closed)
+			}()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case data := <-c.messages:
+					if err := websocket.Message.Send(ws, string(data)); err != nil {
+						return
+					}
+				}
+			}
+		}
+		websocket.Server{Handler: handler, Handshake: sameOrigin}.ServeHTTP(w, r)
+	})
+}
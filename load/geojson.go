@@ -0,0 +1,157 @@
+package load
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/export"
+)
+
+// geoJSONObject is the top level object of a file, which may be a feature collection, a feature or a geometry
+type geoJSONObject struct {
+	export.GeoJSONGeometry
+	Features   []*export.GeoJSONFeature `json:"features"`
+	Geometry   *export.GeoJSONGeometry  `json:"geometry"`
+	Properties map[string]interface{}   `json:"properties"`
+}
+
+// geoJSONCoordinates decodes the coordinates of a geometry in to nested slices of positions
+func geoJSONCoordinates(geometry *export.GeoJSONGeometry, coordinates interface{}) error {
+	b, err := json.Marshal(geometry.Coordinates)
+	if err != nil {
+		return geoJSONSyntax(err)
+	}
+	if err := json.Unmarshal(b, coordinates); err != nil {
+		return geoJSONSyntax(err)
+	}
+	return nil
+}
+
+func geoJSONLocations(positions [][]float64) ([]*api.Location, error) {
+	locations := make([]*api.Location, len(positions))
+	for i, position := range positions {
+		if len(position) < 2 {
+			return nil, errors.New("positions need a longitude and a latitude")
+		}
+		locations[i] = &api.Location{Lon: position[0], Lat: position[1]}
+		if len(position) > 2 {
+			locations[i].Alt = position[2]
+		}
+	}
+	return locations, nil
+}
+
+func geoJSONSyntax(err error) error {
+	return &ErrSyntax{"GeoJSON", err}
+}
+
+func (s *Shapes) addGeoJSONFeature(name string, feature *export.GeoJSONFeature) error {
+	if featureName, ok := feature.Properties["name"].(string); ok {
+		name = featureName
+	}
+	return s.addGeoJSONGeometry(name, feature.Geometry)
+}
+
+func (s *Shapes) addGeoJSONGeometry(name string, geometry *export.GeoJSONGeometry) error {
+	if geometry == nil {
+		return nil
+	}
+
+	switch geometry.Type {
+	case "GeometryCollection":
+		for _, g := range geometry.Geometries {
+			if err := s.addGeoJSONGeometry(name, g); err != nil {
+				return err
+			}
+		}
+	case "LineString":
+		var line [][]float64
+		if err := geoJSONCoordinates(geometry, &line); err != nil {
+			return err
+		}
+		return s.addGeoJSONLine(name, line)
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := geoJSONCoordinates(geometry, &lines); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := s.addGeoJSONLine(name, line); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var rings [][][]float64
+		if err := geoJSONCoordinates(geometry, &rings); err != nil {
+			return err
+		}
+		return s.addGeoJSONPolygon(name, rings)
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := geoJSONCoordinates(geometry, &polygons); err != nil {
+			return err
+		}
+		for _, rings := range polygons {
+			if err := s.addGeoJSONPolygon(name, rings); err != nil {
+				return err
+			}
+		}
+	case "Point", "MultiPoint":
+		// Single points are neither routes nor areas
+	default:
+		return geoJSONSyntax(fmt.Errorf("unknown type %q", geometry.Type))
+	}
+	return nil
+}
+
+func (s *Shapes) addGeoJSONLine(name string, line [][]float64) error {
+	locations, err := geoJSONLocations(line)
+	if err != nil {
+		return geoJSONSyntax(err)
+	}
+	return s.addRoute(name, locations)
+}
+
+// addGeoJSONPolygon adds the exterior ring of a polygon as an area
+func (s *Shapes) addGeoJSONPolygon(name string, rings [][][]float64) error {
+	if len(rings) == 0 {
+		return &ErrGeometry{name, "a polygon needs an exterior ring"}
+	}
+	ring, err := geoJSONLocations(rings[0])
+	if err != nil {
+		return geoJSONSyntax(err)
+	}
+	return s.addArea(name, ring)
+}
+
+// GeoJSON reads the line strings and polygons of a GeoJSON file, which may be a geometry, a feature or
+// a feature collection, line strings become routes and the exterior rings of polygons become areas
+func GeoJSON(r io.Reader) (*Shapes, error) {
+	var object geoJSONObject
+	if err := json.NewDecoder(r).Decode(&object); err != nil {
+		return nil, geoJSONSyntax(err)
+	}
+
+	shapes := &Shapes{}
+	switch object.Type {
+	case "FeatureCollection":
+		for _, feature := range object.Features {
+			if err := shapes.addGeoJSONFeature("", feature); err != nil {
+				return nil, err
+			}
+		}
+	case "Feature":
+		feature := &export.GeoJSONFeature{Geometry: object.Geometry, Properties: object.Properties}
+		if err := shapes.addGeoJSONFeature("", feature); err != nil {
+			return nil, err
+		}
+	default:
+		if err := shapes.addGeoJSONGeometry("", &object.GeoJSONGeometry); err != nil {
+			return nil, err
+		}
+	}
+	return shapes.check()
+}
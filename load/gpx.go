@@ -0,0 +1,77 @@
+package load
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// gpxPoint has its coordinates as pointers to tell missing ones from zero
+type gpxPoint struct {
+	Lat       *float64 `xml:"lat,attr"`
+	Lon       *float64 `xml:"lon,attr"`
+	Elevation float64  `xml:"ele"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpx struct {
+	Tracks []gpxTrack `xml:"trk"`
+	Routes []gpxRoute `xml:"rte"`
+}
+
+func gpxLocations(name string, points []gpxPoint) ([]*api.Location, error) {
+	locations := make([]*api.Location, len(points))
+	for i, point := range points {
+		if point.Lat == nil || point.Lon == nil {
+			return nil, &ErrGeometry{name, "a point is missing its latitude or longitude"}
+		}
+		locations[i] = &api.Location{Lat: *point.Lat, Lon: *point.Lon, Alt: point.Elevation}
+	}
+	return locations, nil
+}
+
+// GPX reads the routes and tracks of a GPX file, every track segment becomes a route of its own
+func GPX(r io.Reader) (*Shapes, error) {
+	var doc gpx
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, &ErrSyntax{"GPX", err}
+	}
+
+	shapes := &Shapes{}
+	for _, route := range doc.Routes {
+		locations, err := gpxLocations(route.Name, route.Points)
+		if err != nil {
+			return nil, err
+		}
+		if err := shapes.addRoute(route.Name, locations); err != nil {
+			return nil, err
+		}
+	}
+	for _, track := range doc.Tracks {
+		for _, segment := range track.Segments {
+			locations, err := gpxLocations(track.Name, segment.Points)
+			if err != nil {
+				return nil, err
+			}
+			if err := shapes.addRoute(track.Name, locations); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return shapes.check()
+}
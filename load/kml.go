@@ -0,0 +1,117 @@
+package load
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+type kmlLineString struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Coordinates string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlGeometry struct {
+	LineStrings   []kmlLineString `xml:"LineString"`
+	Polygons      []kmlPolygon    `xml:"Polygon"`
+	MultiGeometry []kmlGeometry   `xml:"MultiGeometry"`
+}
+
+type kmlPlacemark struct {
+	Name string `xml:"name"`
+	kmlGeometry
+}
+
+type kmlContainer struct {
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+	Folders    []kmlContainer `xml:"Folder"`
+	Documents  []kmlContainer `xml:"Document"`
+}
+
+// kmlCoordinates parses whitespace separated tuples of longitude, latitude and an optional altitude
+func kmlCoordinates(coordinates string) ([]*api.Location, error) {
+	tuples := strings.Fields(coordinates)
+	locations := make([]*api.Location, len(tuples))
+	for i, tuple := range tuples {
+		values := strings.Split(tuple, ",")
+		if len(values) < 2 || len(values) > 3 {
+			return nil, errors.New("coordinates have to be longitude,latitude[,altitude]")
+		}
+		parsed := make([]float64, len(values))
+		for j, value := range values {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, err
+			}
+			parsed[j] = f
+		}
+		locations[i] = &api.Location{Lon: parsed[0], Lat: parsed[1]}
+		if len(parsed) == 3 {
+			locations[i].Alt = parsed[2]
+		}
+	}
+	return locations, nil
+}
+
+func (s *Shapes) addKMLGeometry(name string, geometry kmlGeometry) error {
+	for _, line := range geometry.LineStrings {
+		locations, err := kmlCoordinates(line.Coordinates)
+		if err != nil {
+			return &ErrSyntax{"KML", err}
+		}
+		if err := s.addRoute(name, locations); err != nil {
+			return err
+		}
+	}
+	for _, polygon := range geometry.Polygons {
+		ring, err := kmlCoordinates(polygon.Coordinates)
+		if err != nil {
+			return &ErrSyntax{"KML", err}
+		}
+		if err := s.addArea(name, ring); err != nil {
+			return err
+		}
+	}
+	for _, multi := range geometry.MultiGeometry {
+		if err := s.addKMLGeometry(name, multi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Shapes) addKMLContainer(container kmlContainer) error {
+	for _, placemark := range container.Placemarks {
+		if err := s.addKMLGeometry(placemark.Name, placemark.kmlGeometry); err != nil {
+			return err
+		}
+	}
+	for _, nested := range append(container.Documents, container.Folders...) {
+		if err := s.addKMLContainer(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KML reads the line strings and polygons of the placemarks in a KML file,
+// line strings become routes and the outer boundaries of polygons become areas
+func KML(r io.Reader) (*Shapes, error) {
+	var doc kmlContainer
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, &ErrSyntax{"KML", err}
+	}
+
+	shapes := &Shapes{}
+	if err := shapes.addKMLContainer(doc); err != nil {
+		return nil, err
+	}
+	return shapes.check()
+}
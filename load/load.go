@@ -0,0 +1,126 @@
+// Package load reads walking routes and scan areas from GPX, KML and GeoJSON files
+package load
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/scan"
+)
+
+// ErrUnknownFormat happens when the format of a file cannot be told from its extension
+var ErrUnknownFormat = errors.New("load: Unknown file format")
+
+// ErrEmpty happens when a file contains no routes or areas
+var ErrEmpty = errors.New("load: The file contains no routes or areas")
+
+// ErrSyntax happens when a file cannot be decoded
+type ErrSyntax struct {
+	Format string
+	Err    error
+}
+
+func (e *ErrSyntax) Error() string {
+	return fmt.Sprintf("load: Malformed %s file: %s", e.Format, e.Err)
+}
+
+// ErrGeometry happens when a route or area in a file is not valid
+type ErrGeometry struct {
+	Name   string
+	Reason string
+}
+
+func (e *ErrGeometry) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("load: Invalid geometry: %s", e.Reason)
+	}
+	return fmt.Sprintf("load: Invalid geometry %q: %s", e.Name, e.Reason)
+}
+
+// Route is a named line of locations to walk along
+type Route struct {
+	Name      string
+	Locations []*api.Location
+}
+
+// Area is a named polygon to scan, holes are not supported and left out
+type Area struct {
+	Name    string
+	Polygon scan.Polygon
+}
+
+// Shapes are the routes and areas found in a file
+type Shapes struct {
+	Routes []*Route
+	Areas  []*Area
+}
+
+func (s *Shapes) addRoute(name string, locations []*api.Location) error {
+	if len(locations) < 2 {
+		return &ErrGeometry{name, "a route needs at least two points"}
+	}
+	for _, location := range locations {
+		if err := validate(name, location); err != nil {
+			return err
+		}
+	}
+	s.Routes = append(s.Routes, &Route{Name: name, Locations: locations})
+	return nil
+}
+
+func (s *Shapes) addArea(name string, ring []*api.Location) error {
+	polygon := make(scan.Polygon, len(ring))
+	for i, location := range ring {
+		if err := validate(name, location); err != nil {
+			return err
+		}
+		polygon[i] = *location
+	}
+	if _, err := polygon.Region(); err != nil {
+		return &ErrGeometry{name, "an area needs at least three distinct points"}
+	}
+	s.Areas = append(s.Areas, &Area{Name: name, Polygon: polygon})
+	return nil
+}
+
+func validate(name string, l *api.Location) error {
+	if !l.IsValid() {
+		return &ErrGeometry{name, fmt.Sprintf("coordinate %f,%f is out of range", l.Lat, l.Lon)}
+	}
+	return nil
+}
+
+func (s *Shapes) check() (*Shapes, error) {
+	if len(s.Routes) == 0 && len(s.Areas) == 0 {
+		return nil, ErrEmpty
+	}
+	return s, nil
+}
+
+// File reads the shapes of a file, telling the format from the extension
+func File(path string) (*Shapes, error) {
+	var decode func(io.Reader) (*Shapes, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gpx":
+		decode = GPX
+	case ".kml":
+		decode = KML
+	case ".geojson", ".json":
+		decode = GeoJSON
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decode(f)
+}
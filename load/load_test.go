@@ -0,0 +1,119 @@
+package load
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGPX = `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <rte><name>loop</name><rtept lat="52.52" lon="13.40"/><rtept lat="52.53" lon="13.41"/></rte>
+  <trk><name>walk</name>
+    <trkseg><trkpt lat="52.52" lon="13.40"><ele>34</ele></trkpt><trkpt lat="52.53" lon="13.41"/></trkseg>
+    <trkseg><trkpt lat="52.54" lon="13.42"/><trkpt lat="52.55" lon="13.43"/></trkseg>
+  </trk>
+</gpx>`
+
+const testKML = `<?xml version="1.0"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Document><Folder>
+  <Placemark><name>walk</name><LineString><coordinates>13.40,52.52,34 13.41,52.53</coordinates></LineString></Placemark>
+  <Placemark><name>park</name><MultiGeometry><Polygon><outerBoundaryIs><LinearRing>
+    <coordinates>13.40,52.52 13.41,52.52 13.41,52.53 13.40,52.52</coordinates>
+  </LinearRing></outerBoundaryIs></Polygon></MultiGeometry></Placemark>
+</Folder></Document></kml>`
+
+const testGeoJSON = `{"type": "FeatureCollection", "features": [
+  {"type": "Feature", "properties": {"name": "walk"}, "geometry": {"type": "LineString", "coordinates": [[13.40, 52.52], [13.41, 52.53]]}},
+  {"type": "Feature", "properties": {"name": "park"}, "geometry": {"type": "Polygon", "coordinates": [[[13.40, 52.52], [13.41, 52.52], [13.41, 52.53], [13.40, 52.52]]]}},
+  {"type": "Feature", "properties": {}, "geometry": {"type": "Point", "coordinates": [13.40, 52.52]}}
+]}`
+
+func TestGPX(t *testing.T) {
+	shapes, err := GPX(strings.NewReader(testGPX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes.Routes) != 3 {
+		t.Fatalf("expected a route for the route and every track segment, got %d", len(shapes.Routes))
+	}
+	if shapes.Routes[1].Name != "walk" || shapes.Routes[1].Locations[0].Alt != 34 {
+		t.Errorf("expected the track with its elevation, got %v", shapes.Routes[1])
+	}
+}
+
+func TestKML(t *testing.T) {
+	shapes, err := KML(strings.NewReader(testKML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes.Routes) != 1 || len(shapes.Areas) != 1 {
+		t.Fatalf("expected a route and an area, got %d and %d", len(shapes.Routes), len(shapes.Areas))
+	}
+	if l := shapes.Routes[0].Locations[0]; l.Lat != 52.52 || l.Lon != 13.40 || l.Alt != 34 {
+		t.Errorf("expected coordinates in longitude, latitude order, got %v", l)
+	}
+	if shapes.Areas[0].Name != "park" || len(shapes.Areas[0].Polygon) != 4 {
+		t.Errorf("expected the park polygon, got %v", shapes.Areas[0])
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	shapes, err := GeoJSON(strings.NewReader(testGeoJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes.Routes) != 1 || len(shapes.Areas) != 1 {
+		t.Fatalf("expected a route and an area, got %d and %d", len(shapes.Routes), len(shapes.Areas))
+	}
+	if shapes.Routes[0].Name != "walk" || shapes.Areas[0].Name != "park" {
+		t.Errorf("expected the names of the features")
+	}
+}
+
+func TestInvalidFiles(t *testing.T) {
+	tests := map[string]struct {
+		decode func(string) error
+		input  string
+	}{
+		"malformed xml": {gpxError, `<gpx><rte>`},
+		"out of range":  {gpxError, `<gpx><rte><rtept lat="91" lon="0"/><rtept lat="0" lon="0"/></rte></gpx>`},
+		"missing lat":   {gpxError, `<gpx><rte><rtept lon="13"/><rtept lat="52" lon="13"/></rte></gpx>`},
+		"missing lon":   {gpxError, `<gpx><trk><trkseg><trkpt lat="52"/><trkpt lat="52" lon="13"/></trkseg></trk></gpx>`},
+		"short route":   {kmlError, `<kml><Placemark><LineString><coordinates>13,52</coordinates></LineString></Placemark></kml>`},
+		"bad tuple":     {kmlError, `<kml><Placemark><LineString><coordinates>13;52 14;53</coordinates></LineString></Placemark></kml>`},
+		"empty":         {kmlError, `<kml></kml>`},
+		"unknown type":  {geoJSONError, `{"type": "Circle"}`},
+		"bad polygon":   {geoJSONError, `{"type": "Polygon", "coordinates": [[[13, 52], [14, 52], [13, 52]]]}`},
+		"bad json":      {geoJSONError, `{"type": "LineString", "coordinates": "13,52"}`},
+	}
+
+	for name, test := range tests {
+		err := test.decode(test.input)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		switch err.(type) {
+		case *ErrSyntax, *ErrGeometry:
+		default:
+			if err != ErrEmpty {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+	}
+}
+
+func gpxError(input string) error {
+	_, err := GPX(strings.NewReader(input))
+	return err
+}
+
+func kmlError(input string) error {
+	_, err := KML(strings.NewReader(input))
+	return err
+}
+
+func geoJSONError(input string) error {
+	_, err := GeoJSON(strings.NewReader(input))
+	return err
+}
@@ -3,8 +3,8 @@ package main
 import (
 	"os"
 
-	"github.com/pogodevorg/pgoapi-go/api"
-	"github.com/pogodevorg/pgoapi-go/cli"
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/cli"
 )
 
 func main() {
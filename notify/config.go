@@ -0,0 +1,225 @@
+// Package notify evaluates user defined rules against map events and sends the matches to sinks
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Rule types
+const (
+	// RulePokemon matches Pokémon appearing on the map
+	RulePokemon = "pokemon"
+	// RuleGymTeam matches gyms changing team
+	RuleGymTeam = "gym_team"
+)
+
+// ErrConfig happens when a rule or sink in the configuration is not valid
+type ErrConfig struct {
+	Reason string
+}
+
+func (e *ErrConfig) Error() string {
+	return fmt.Sprintf("notify: Invalid configuration: %s", e.Reason)
+}
+
+// Duration is a time.Duration read from a string like "10m" in the configuration file
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Circle is the area within a radius in metres around a point
+type Circle struct {
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Radius float64 `json:"radius"`
+}
+
+// GetLatitude returns the latitude of the center
+func (c *Circle) GetLatitude() float64 { return c.Lat }
+
+// GetLongitude returns the longitude of the center
+func (c *Circle) GetLongitude() float64 { return c.Lon }
+
+// Window is a daily period between two local times written as "15:04", it passes midnight when From is after To
+type Window struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	from int
+	to   int
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *Window) parse() (err error) {
+	if w.from, err = minuteOfDay(w.From); err != nil {
+		return err
+	}
+	w.to, err = minuteOfDay(w.To)
+	return err
+}
+
+// Contains returns whether or not the local time of day is inside the window
+func (w *Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.from <= w.to {
+		return minute >= w.from && minute < w.to
+	}
+	return minute >= w.from || minute < w.to
+}
+
+// Rule describes the events a user wants to be notified about, all of its conditions have to match
+type Rule struct {
+	Name string `json:"name"`
+	// Type is either RulePokemon or RuleGymTeam
+	Type string `json:"type"`
+	// Pokemon limits a Pokémon rule to the Pokémon ids, or matches any Pokémon when it is empty
+	Pokemon []int32 `json:"pokemon,omitempty"`
+	// MinIV is the lowest IV in percent, Pokémon whose IVs are not known are not filtered by it
+	MinIV float64 `json:"min_iv,omitempty"`
+	// Forts limits a gym rule to the forts, or matches any gym when it is empty
+	Forts  []string `json:"forts,omitempty"`
+	Near   *Circle  `json:"near,omitempty"`
+	Window *Window  `json:"window,omitempty"`
+	// Cooldown is the least time between two notifications of the rule
+	Cooldown Duration `json:"cooldown,omitempty"`
+	// Sinks are the names of the sinks notifications are sent to
+	Sinks []string `json:"sinks"`
+}
+
+// SinkConfig describes where notifications are sent, the fields used depend on the type
+type SinkConfig struct {
+	// Type is either "stdout", "webhook" or "email"
+	Type string `json:"type"`
+	// URL is where a webhook posts notifications to
+	URL string `json:"url,omitempty"`
+	// Addr is the host and port of the SMTP server mail is sent through
+	Addr     string   `json:"addr,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+// Config is the content of a configuration file
+type Config struct {
+	Sinks map[string]*SinkConfig `json:"sinks"`
+	Rules []*Rule                `json:"rules"`
+	// Dedup is how long a notified Pokémon or gym change is remembered so it is only notified once, an hour by default
+	Dedup Duration `json:"dedup,omitempty"`
+}
+
+// ParseConfig reads and validates a JSON configuration
+func ParseConfig(r io.Reader) (*Config, error) {
+	config := &Config{}
+	if err := json.NewDecoder(r).Decode(config); err != nil {
+		return nil, err
+	}
+	if config.Dedup == 0 {
+		config.Dedup = Duration(time.Hour)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LoadConfig reads and validates a JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseConfig(f)
+}
+
+func configError(format string, a ...interface{}) error {
+	return &ErrConfig{fmt.Sprintf(format, a...)}
+}
+
+func (c *Config) validate() error {
+	for name, sink := range c.Sinks {
+		switch sink.Type {
+		case "stdout":
+		case "webhook":
+			if sink.URL == "" {
+				return configError("sink %q has no url", name)
+			}
+		case "email":
+			if sink.Addr == "" || sink.From == "" || len(sink.To) == 0 {
+				return configError("sink %q needs an addr, from and to", name)
+			}
+		default:
+			return configError("sink %q has unknown type %q", name, sink.Type)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if names[rule.Name] {
+			return configError("rule %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Type {
+		case RulePokemon:
+			if len(rule.Forts) > 0 {
+				return configError("rule %q matches Pokémon and can not have forts", rule.Name)
+			}
+		case RuleGymTeam:
+			if len(rule.Pokemon) > 0 || rule.MinIV > 0 {
+				return configError("rule %q matches gyms and can not have Pokémon or IVs", rule.Name)
+			}
+		default:
+			return configError("rule %q has unknown type %q", rule.Name, rule.Type)
+		}
+		if rule.Near != nil && rule.Near.Radius <= 0 {
+			return configError("rule %q needs a positive radius", rule.Name)
+		}
+		if rule.Window != nil {
+			if err := rule.Window.parse(); err != nil {
+				return configError("rule %q has an invalid window: %s", rule.Name, err)
+			}
+		}
+		if len(rule.Sinks) == 0 {
+			return configError("rule %q has no sinks", rule.Name)
+		}
+		for _, sink := range rule.Sinks {
+			if _, ok := c.Sinks[sink]; !ok {
+				return configError("rule %q sends to unknown sink %q", rule.Name, sink)
+			}
+		}
+	}
+	return nil
+}
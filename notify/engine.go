@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/events"
+)
+
+// match is what a rule knows about an event it matched
+type match struct {
+	// key identifies the Pokémon or gym change so it is only notified once
+	key     string
+	subject string
+	text    string
+	lat     float64
+	lon     float64
+}
+
+type ruleState struct {
+	rule     *Rule
+	sinks    []Sink
+	notified time.Time
+	seen     map[string]time.Time
+}
+
+// Engine is a feed evaluating the rules against the changes of every map response
+//
+// Sinks are called as events are pushed, wrap the engine in an api.ChanFeed to keep slow sinks
+// from holding up the session.
+//
+// Pushed responses are compared by a differ of the engine's own. When the responses are already diffed
+// for something else, like the web view, register the engine with that differ using Handle instead of
+// pushing to both, so every response is only compared once.
+type Engine struct {
+	mutex  sync.Mutex
+	differ *events.Differ
+	rules  []*ruleState
+	dedup  time.Duration
+	now    func() time.Time
+	err    error
+}
+
+// NewEngine constructs an engine sending the matches of the rules to the sinks named in the configuration
+func NewEngine(config *Config) (*Engine, error) {
+	sinks := make(map[string]Sink, len(config.Sinks))
+	for name, sinkConfig := range config.Sinks {
+		sink, err := NewSink(sinkConfig)
+		if err != nil {
+			return nil, err
+		}
+		sinks[name] = sink
+	}
+	return NewEngineWithSinks(config, sinks)
+}
+
+// NewEngineWithSinks constructs an engine sending the matches of the rules to the sinks by name,
+// instead of constructing the sinks described in the configuration
+//
+// The configuration does not have to be read by ParseConfig, the time windows of the rules are parsed here.
+func NewEngineWithSinks(config *Config, sinks map[string]Sink) (*Engine, error) {
+	e := &Engine{
+		differ: events.NewDiffer(),
+		dedup:  time.Duration(config.Dedup),
+		now:    time.Now,
+	}
+	if e.dedup == 0 {
+		e.dedup = time.Hour
+	}
+
+	for _, rule := range config.Rules {
+		if rule.Window != nil {
+			if err := rule.Window.parse(); err != nil {
+				return nil, configError("rule %q has an invalid window: %s", rule.Name, err)
+			}
+		}
+		state := &ruleState{
+			rule: rule,
+			seen: make(map[string]time.Time),
+		}
+		for _, name := range rule.Sinks {
+			sink, ok := sinks[name]
+			if !ok {
+				return nil, configError("rule %q sends to unknown sink %q", rule.Name, name)
+			}
+			state.sinks = append(state.sinks, sink)
+		}
+		e.rules = append(e.rules, state)
+	}
+
+	e.differ.Handle(e)
+	return e, nil
+}
+
+// SetClock replaces the clock used for time windows, deduplication and cooldowns
+func (e *Engine) SetClock(now func() time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.now = now
+}
+
+// Push compares map responses to the previous ones and evaluates the rules against the changes
+func (e *Engine) Push(entry interface{}) {
+	e.differ.Push(entry)
+}
+
+// PushEntry evaluates the rules against the changes of the map response in the entry
+func (e *Engine) PushEntry(entry *api.FeedEntry) {
+	e.differ.Push(entry.Message)
+}
+
+// Err returns the last error a sink failed with
+func (e *Engine) Err() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.err
+}
+
+// Handle evaluates the rules against an event, so the engine can also be registered with another differ
+func (e *Engine) Handle(event events.Event) {
+	e.mutex.Lock()
+	now := e.now()
+	type delivery struct {
+		sinks        []Sink
+		notification *Notification
+	}
+	deliveries := make([]delivery, 0)
+	for _, state := range e.rules {
+		m, ok := state.rule.match(event, now)
+		if !ok || !state.admit(m.key, now, e.dedup) {
+			continue
+		}
+		deliveries = append(deliveries, delivery{state.sinks, &Notification{
+			Rule:    state.rule.Name,
+			Time:    now,
+			Subject: m.subject,
+			Text:    m.text,
+			Lat:     m.lat,
+			Lon:     m.lon,
+			Event:   event,
+		}})
+	}
+	e.mutex.Unlock()
+
+	for _, d := range deliveries {
+		for _, sink := range d.sinks {
+			if err := sink.Send(d.notification); err != nil {
+				e.mutex.Lock()
+				e.err = err
+				e.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// admit decides whether or not a match is notified, remembering it if it is
+func (s *ruleState) admit(key string, now time.Time, dedup time.Duration) bool {
+	for k, seen := range s.seen {
+		if now.Sub(seen) >= dedup {
+			delete(s.seen, k)
+		}
+	}
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	if !s.notified.IsZero() && now.Sub(s.notified) < time.Duration(s.rule.Cooldown) {
+		return false
+	}
+	s.seen[key] = now
+	s.notified = now
+	return true
+}
+
+// IV returns the sum of the individual values of a Pokémon in percent,
+// and false if they are not known because the response did not include any
+func IV(pokemon *protos.PokemonData) (float64, bool) {
+	sum := pokemon.GetIndividualAttack() + pokemon.GetIndividualDefense() + pokemon.GetIndividualStamina()
+	if sum == 0 {
+		return 0, false
+	}
+	return float64(sum) / 45 * 100, true
+}
+
+func (r *Rule) matchCommon(lat, lon float64, now time.Time) bool {
+	if r.Window != nil && !r.Window.Contains(now) {
+		return false
+	}
+	if r.Near != nil && api.Distance(r.Near, &api.Location{Lat: lat, Lon: lon}) > r.Near.Radius {
+		return false
+	}
+	return true
+}
+
+func (r *Rule) match(event events.Event, now time.Time) (*match, bool) {
+	switch e := event.(type) {
+	case *events.PokemonAppeared:
+		if r.Type == RulePokemon {
+			return r.matchPokemon(e.Pokemon, now)
+		}
+	case *events.GymTeamChanged:
+		if r.Type == RuleGymTeam {
+			return r.matchGym(e, now)
+		}
+	}
+	return nil, false
+}
+
+func (r *Rule) matchPokemon(pokemon *protos.WildPokemon, now time.Time) (*match, bool) {
+	id := pokemon.PokemonData.GetPokemonId()
+	if len(r.Pokemon) > 0 {
+		wanted := false
+		for _, p := range r.Pokemon {
+			if protos.PokemonId(p) == id {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			return nil, false
+		}
+	}
+	iv, known := IV(pokemon.PokemonData)
+	if known && iv < r.MinIV {
+		return nil, false
+	}
+	if !r.matchCommon(pokemon.Latitude, pokemon.Longitude, now) {
+		return nil, false
+	}
+
+	m := &match{
+		key:     fmt.Sprintf("pokemon:%d", pokemon.EncounterId),
+		subject: fmt.Sprintf("%s appeared", id),
+		lat:     pokemon.Latitude,
+		lon:     pokemon.Longitude,
+	}
+	m.text = fmt.Sprintf("%s at %.6f, %.6f", id, pokemon.Latitude, pokemon.Longitude)
+	if known {
+		m.text += fmt.Sprintf(" with %.0f%% IV", iv)
+	}
+	// The time until the Pokémon is hidden is only known shortly before it despawns
+	if pokemon.TimeTillHiddenMs > 0 {
+		despawn := time.Unix(0, (pokemon.LastModifiedTimestampMs+int64(pokemon.TimeTillHiddenMs))*int64(time.Millisecond))
+		m.text += fmt.Sprintf(" until %s", despawn.In(now.Location()).Format("15:04:05"))
+	}
+	return m, true
+}
+
+func (r *Rule) matchGym(e *events.GymTeamChanged, now time.Time) (*match, bool) {
+	fort := e.Fort
+	if len(r.Forts) > 0 {
+		wanted := false
+		for _, id := range r.Forts {
+			if id == fort.Id {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			return nil, false
+		}
+	}
+	if !r.matchCommon(fort.Latitude, fort.Longitude, now) {
+		return nil, false
+	}
+
+	return &match{
+		key:     fmt.Sprintf("gym:%s:%s:%d", fort.Id, fort.OwnedByTeam, fort.LastModifiedTimestampMs),
+		subject: fmt.Sprintf("Gym %s was taken by %s", fort.Id, fort.OwnedByTeam),
+		text:    fmt.Sprintf("Gym %s at %.6f, %.6f changed from %s to %s", fort.Id, fort.Latitude, fort.Longitude, e.Previous, fort.OwnedByTeam),
+		lat:     fort.Latitude,
+		lon:     fort.Longitude,
+	}, true
+}
@@ -0,0 +1,273 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/events"
+)
+
+const testConfig = `{
+	"sinks": {
+		"out": {"type": "stdout"},
+		"hook": {"type": "webhook", "url": "http://localhost/hook"}
+	},
+	"rules": [
+		{
+			"name": "dragons",
+			"type": "pokemon",
+			"pokemon": [147, 148, 149],
+			"min_iv": 80,
+			"near": {"lat": 59.3293, "lon": 18.0686, "radius": 1000},
+			"window": {"from": "22:00", "to": "06:00"},
+			"cooldown": "10m",
+			"sinks": ["out"]
+		},
+		{
+			"name": "home gym",
+			"type": "gym_team",
+			"forts": ["home"],
+			"sinks": ["out", "hook"]
+		}
+	]
+}`
+
+type recordingSink struct {
+	notifications []*Notification
+}
+
+func (s *recordingSink) Send(n *Notification) error {
+	s.notifications = append(s.notifications, n)
+	return nil
+}
+
+func newTestEngine(t *testing.T) (*Engine, *recordingSink, *time.Time) {
+	config, err := ParseConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	e, err := NewEngineWithSinks(config, map[string]Sink{"out": sink, "hook": sink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2016, 8, 1, 23, 0, 0, 0, time.Local)
+	e.SetClock(func() time.Time { return now })
+	return e, sink, &now
+}
+
+func dratini(encounterID uint64, attack int32, lat float64) *events.PokemonAppeared {
+	return &events.PokemonAppeared{Pokemon: &protos.WildPokemon{
+		EncounterId: encounterID,
+		Latitude:    lat,
+		Longitude:   18.0686,
+		PokemonData: &protos.PokemonData{
+			PokemonId:         protos.PokemonId(147),
+			IndividualAttack:  attack,
+			IndividualDefense: 15,
+			IndividualStamina: 15,
+		},
+	}}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(config.Rules[0].Cooldown) != 10*time.Minute || time.Duration(config.Dedup) != time.Hour {
+		t.Errorf("unexpected durations %v and %v", config.Rules[0].Cooldown, config.Dedup)
+	}
+
+	invalid := []string{
+		`{"rules": [{"type": "pokemon", "sinks": ["missing"]}]}`,
+		`{"sinks": {"out": {"type": "stdout"}}, "rules": [{"type": "raid", "sinks": ["out"]}]}`,
+		`{"sinks": {"out": {"type": "stdout"}}, "rules": [{"type": "gym_team", "min_iv": 50, "sinks": ["out"]}]}`,
+		`{"sinks": {"out": {"type": "stdout"}}, "rules": [{"type": "pokemon", "window": {"from": "25:00", "to": "01:00"}, "sinks": ["out"]}]}`,
+		`{"sinks": {"mail": {"type": "email", "addr": "localhost:25"}}, "rules": []}`,
+	}
+	for _, s := range invalid {
+		if _, err := ParseConfig(strings.NewReader(s)); err == nil {
+			t.Errorf("expected %s to be invalid", s)
+		} else if _, ok := err.(*ErrConfig); !ok {
+			t.Errorf("expected a configuration error, got %v", err)
+		}
+	}
+}
+
+func TestEngineParsesWindows(t *testing.T) {
+	// A configuration built in code has not been through ParseConfig
+	config := &Config{Rules: []*Rule{{
+		Name:   "night",
+		Type:   RulePokemon,
+		Window: &Window{From: "22:00", To: "06:00"},
+		Sinks:  []string{"out"},
+	}}}
+	sink := &recordingSink{}
+	e, err := NewEngineWithSinks(config, map[string]Sink{"out": sink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetClock(func() time.Time { return time.Date(2016, 8, 1, 23, 0, 0, 0, time.Local) })
+	e.Handle(dratini(1, 15, 59.3293))
+	if len(sink.notifications) != 1 {
+		t.Errorf("expected a notification within the window, got %d", len(sink.notifications))
+	}
+
+	config.Rules[0].Window = &Window{From: "25:00", To: "06:00"}
+	if _, err := NewEngineWithSinks(config, map[string]Sink{"out": sink}); err == nil {
+		t.Error("expected an invalid window to be rejected")
+	}
+}
+
+func TestPokemonRule(t *testing.T) {
+	e, sink, now := newTestEngine(t)
+
+	e.Handle(dratini(1, 15, 59.3293))
+	e.Handle(dratini(1, 15, 59.3293))
+	if len(sink.notifications) != 1 || sink.notifications[0].Rule != "dragons" {
+		t.Fatalf("expected one notification, got %d", len(sink.notifications))
+	}
+	if !strings.Contains(sink.notifications[0].Text, "100% IV") {
+		t.Errorf("expected the IV in %q", sink.notifications[0].Text)
+	}
+
+	// Too weak, too far away and within the cooldown
+	*now = now.Add(11 * time.Minute)
+	e.Handle(dratini(2, 0, 59.3293))
+	e.Handle(dratini(3, 15, 59.5))
+	e.Handle(&events.PokemonAppeared{Pokemon: &protos.WildPokemon{EncounterId: 4, PokemonData: &protos.PokemonData{PokemonId: protos.PokemonId(16)}}})
+	if len(sink.notifications) != 1 {
+		t.Fatalf("expected no more notifications, got %d", len(sink.notifications))
+	}
+
+	// Unknown IVs are not filtered
+	unknown := dratini(5, 0, 59.3293)
+	unknown.Pokemon.PokemonData.IndividualDefense = 0
+	unknown.Pokemon.PokemonData.IndividualStamina = 0
+	e.Handle(unknown)
+	e.Handle(dratini(6, 15, 59.3293))
+	if len(sink.notifications) != 2 {
+		t.Fatalf("expected the cooldown to hold back the second match, got %d notifications", len(sink.notifications))
+	}
+
+	// Outside of the window
+	*now = time.Date(2016, 8, 2, 12, 0, 0, 0, time.Local)
+	e.Handle(dratini(7, 15, 59.3293))
+	if len(sink.notifications) != 2 {
+		t.Errorf("expected no notifications outside the window, got %d", len(sink.notifications))
+	}
+}
+
+func TestGymRule(t *testing.T) {
+	e, sink, _ := newTestEngine(t)
+	fort := func(id string, team protos.TeamColor) *protos.FortData {
+		return &protos.FortData{Id: id, Type: protos.FortType_GYM, OwnedByTeam: team}
+	}
+
+	// The engine diffs map responses itself
+	response := func(forts ...*protos.FortData) *protos.GetMapObjectsResponse {
+		return &protos.GetMapObjectsResponse{MapCells: []*protos.MapCell{{S2CellId: 1, Forts: forts}}}
+	}
+	e.Push(response(fort("home", protos.TeamColor_RED), fort("away", protos.TeamColor_RED)))
+	e.Push(response(fort("home", protos.TeamColor_BLUE), fort("away", protos.TeamColor_BLUE)))
+
+	// Both sinks of the rule receive the notification
+	if len(sink.notifications) != 2 || !strings.Contains(sink.notifications[0].Text, "from RED to BLUE") {
+		t.Fatalf("expected the home gym to be notified to both sinks, got %d notifications", len(sink.notifications))
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan *Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&struct {
+			Rule *string `json:"rule"`
+			Text *string `json:"text"`
+		}{&n.Rule, &n.Text})
+		received <- &n
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(&Notification{Rule: "dragons", Text: "DRATINI", Event: dratini(1, 15, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-received; n.Rule != "dragons" || n.Text != "DRATINI" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+}
+
+// serveSMTP accepts a single mail and returns its data
+func serveSMTP(t *testing.T, l net.Listener, data chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost")
+	var message []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 Go ahead")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				message = append(message, line)
+			}
+			data <- strings.Join(message, "")
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	data := make(chan string, 1)
+	go serveSMTP(t, l, data)
+
+	sink, err := NewSink(&SinkConfig{Type: "email", Addr: l.Addr().String(), From: "scanner@localhost", To: []string{"me@localhost"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Send(&Notification{Rule: "dragons", Time: time.Now(), Subject: "DRATINI appeared", Text: "DRATINI at 0, 0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := <-data
+	if !strings.Contains(message, "Subject: DRATINI appeared") || !strings.Contains(message, "DRATINI at 0, 0") {
+		t.Errorf("unexpected mail %q", message)
+	}
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/femot/pgoapi-go/events"
+)
+
+// Notification is a map event matching a rule
+type Notification struct {
+	Rule    string       `json:"rule"`
+	Time    time.Time    `json:"time"`
+	Subject string       `json:"subject"`
+	Text    string       `json:"text"`
+	Lat     float64      `json:"lat"`
+	Lon     float64      `json:"lon"`
+	Event   events.Event `json:"event"`
+}
+
+// Sink is a common interface for the places notifications are sent to
+type Sink interface {
+	Send(n *Notification) error
+}
+
+// WriterSink writes every notification as a line of text
+type WriterSink struct {
+	Writer io.Writer
+}
+
+// Send writes the notification
+func (s *WriterSink) Send(n *Notification) error {
+	_, err := fmt.Fprintf(s.Writer, "%s [%s] %s\n", n.Time.Format(time.RFC3339), n.Rule, n.Text)
+	return err
+}
+
+// WebhookSink posts every notification as a JSON object
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send posts the notification and fails unless the server responds with a 2xx status
+func (s *WebhookSink) Send(n *Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify: Webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailSink mails every notification through an SMTP server
+type EmailSink struct {
+	Addr string
+	// Auth is used to log in to the server unless it is nil
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+// Send mails the notification
+func (s *EmailSink) Send(n *Notification) error {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", s.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", n.Subject)
+	fmt.Fprintf(&message, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&message, "%s\r\n", n.Text)
+	return smtp.SendMail(s.Addr, s.Auth, s.From, s.To, message.Bytes())
+}
+
+// NewSink constructs the sink described by the configuration
+func NewSink(config *SinkConfig) (Sink, error) {
+	switch config.Type {
+	case "stdout":
+		return &WriterSink{Writer: os.Stdout}, nil
+	case "webhook":
+		return &WebhookSink{URL: config.URL, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "email":
+		sink := &EmailSink{Addr: config.Addr, From: config.From, To: config.To}
+		if config.Username != "" {
+			host, _, err := net.SplitHostPort(config.Addr)
+			if err != nil {
+				return nil, err
+			}
+			sink.Auth = smtp.PlainAuth("", config.Username, config.Password, host)
+		}
+		return sink, nil
+	}
+	return nil, configError("unknown sink type %q", config.Type)
+}
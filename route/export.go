@@ -0,0 +1,47 @@
+package route
+
+import (
+	"encoding/json"
+
+	"github.com/femot/pgoapi-go/export"
+)
+
+// GPX returns the loop as a GPX route, with every stop as a waypoint named after its fort
+func (r *Route) GPX() ([]byte, error) {
+	doc := export.NewGPXDocument()
+	for _, stop := range r.Stops {
+		doc.Waypoints = append(doc.Waypoints, export.GPXWaypoint{Lat: stop.Location.Lat, Lon: stop.Location.Lon, Name: stop.Fort.Id})
+	}
+	loop := export.GPXRoute{Name: "route"}
+	for _, location := range r.Locations() {
+		loop.Points = append(loop.Points, export.GPXWaypoint{Lat: location.Lat, Lon: location.Lon})
+	}
+	doc.Routes = append(doc.Routes, loop)
+
+	return doc.Marshal()
+}
+
+// GeoJSON returns the loop as a feature collection of a line string and a point for every stop
+func (r *Route) GeoJSON() ([]byte, error) {
+	line := make([][]float64, 0, len(r.Stops)+2)
+	for _, location := range r.Locations() {
+		line = append(line, export.GeoJSONPosition(location.Lat, location.Lon))
+	}
+
+	collection := export.NewGeoJSONFeatureCollection()
+	collection.Add(&export.GeoJSONGeometry{Type: "LineString", Coordinates: line}, map[string]interface{}{"type": "route", "length": r.Length})
+	for i, stop := range r.Stops {
+		properties := map[string]interface{}{
+			"type":     "stop",
+			"id":       stop.Fort.Id,
+			"order":    i,
+			"distance": stop.Distance,
+		}
+		if !stop.Arrival.IsZero() {
+			properties["arrival"] = stop.Arrival
+		}
+		collection.Add(&export.GeoJSONGeometry{Type: "Point", Coordinates: export.GeoJSONPosition(stop.Location.Lat, stop.Location.Lon)}, properties)
+	}
+
+	return json.Marshal(collection)
+}
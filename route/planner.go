@@ -0,0 +1,161 @@
+package route
+
+import (
+	"time"
+
+	"github.com/femot/pgoapi-go/api"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// Planner builds short loops over forts using a nearest neighbour tour improved by 2-opt
+type Planner struct {
+	// MaxLength is the maximum length of the loop in metres, zero means there is no maximum
+	MaxLength float64
+	// Speed is the walking speed in metres per second used to estimate when each fort is reached,
+	// zero means forts have to be off cooldown already when planning
+	Speed float64
+}
+
+// NewPlanner constructs a planner for loops of at most the given length walked at the given speed
+func NewPlanner(maxLength float64, speed float64) *Planner {
+	return &Planner{
+		MaxLength: maxLength,
+		Speed:     speed,
+	}
+}
+
+// Plan returns a loop from the start visiting as many of the forts as fit within the maximum length,
+// leaving out forts that are still on cooldown when they would be reached
+func (p *Planner) Plan(start *api.Location, forts []*protos.FortData, now time.Time) *Route {
+	tour := p.nearestNeighbour(start, forts, now)
+	p.twoOpt(start, tour, now)
+	return p.route(start, tour, now)
+}
+
+func (p *Planner) within(length float64) bool {
+	return p.MaxLength <= 0 || length <= p.MaxLength
+}
+
+// available checks whether a fort is off cooldown when it is reached after walking the distance
+func (p *Planner) available(fort *protos.FortData, distance float64, now time.Time) bool {
+	arrival := now
+	if p.Speed > 0 {
+		arrival = now.Add(time.Duration(distance / p.Speed * float64(time.Second)))
+	}
+	return fort.CooldownCompleteTimestampMs <= arrival.UnixNano()/int64(time.Millisecond)
+}
+
+func (p *Planner) nearestNeighbour(start *api.Location, forts []*protos.FortData, now time.Time) []*protos.FortData {
+	remaining := make([]*protos.FortData, len(forts))
+	copy(remaining, forts)
+	tour := make([]*protos.FortData, 0, len(forts))
+
+	var current api.LatLng = start
+	travelled := 0.0
+	for len(remaining) > 0 {
+		best, bestDistance := -1, 0.0
+		for i, fort := range remaining {
+			d := api.Distance(current, fort)
+			if best != -1 && d >= bestDistance {
+				continue
+			}
+			// The loop has to be closed by walking back to the start within the maximum length
+			if !p.within(travelled+d+api.Distance(fort, start)) || !p.available(fort, travelled+d, now) {
+				continue
+			}
+			best, bestDistance = i, d
+		}
+		if best == -1 {
+			break
+		}
+
+		tour = append(tour, remaining[best])
+		travelled += bestDistance
+		current = remaining[best]
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	return tour
+}
+
+// twoOpt reverses parts of the tour as long as that makes the loop shorter
+// and every fort is still off cooldown when reached
+func (p *Planner) twoOpt(start *api.Location, tour []*protos.FortData, now time.Time) {
+	point := func(i int) api.LatLng {
+		if i < 0 || i >= len(tour) {
+			return start
+		}
+		return tour[i]
+	}
+
+	improved := true
+	for improved {
+		improved = false
+		for i := 0; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				// Reversing tour[i..j] replaces the edges (i-1, i) and (j, j+1) with (i-1, j) and (i, j+1)
+				before := api.Distance(point(i-1), point(i)) + api.Distance(point(j), point(j+1))
+				after := api.Distance(point(i-1), point(j)) + api.Distance(point(i), point(j+1))
+				if after >= before-1e-6 {
+					continue
+				}
+
+				reverse(tour, i, j)
+				if p.feasible(start, tour, now) {
+					improved = true
+				} else {
+					reverse(tour, i, j)
+				}
+			}
+		}
+	}
+}
+
+func (p *Planner) feasible(start *api.Location, tour []*protos.FortData, now time.Time) bool {
+	var current api.LatLng = start
+	travelled := 0.0
+	for _, fort := range tour {
+		travelled += api.Distance(current, fort)
+		if !p.available(fort, travelled, now) {
+			return false
+		}
+		current = fort
+	}
+	return true
+}
+
+func reverse(tour []*protos.FortData, i, j int) {
+	for ; i < j; i, j = i+1, j-1 {
+		tour[i], tour[j] = tour[j], tour[i]
+	}
+}
+
+func (p *Planner) route(start *api.Location, tour []*protos.FortData, now time.Time) *Route {
+	r := &Route{
+		Start: start,
+		Stops: make([]*Stop, len(tour)),
+	}
+
+	var current api.LatLng = start
+	for i, fort := range tour {
+		r.Length += api.Distance(current, fort)
+		stop := &Stop{
+			Fort: fort,
+			Location: &api.Location{
+				Lat:      fort.Latitude,
+				Lon:      fort.Longitude,
+				Alt:      start.Alt,
+				Accuracy: start.Accuracy,
+			},
+			Distance: r.Length,
+		}
+		if p.Speed > 0 {
+			stop.Arrival = now.Add(time.Duration(r.Length / p.Speed * float64(time.Second)))
+		}
+		r.Stops[i] = stop
+		current = fort
+	}
+	r.Length += api.Distance(current, start)
+
+	return r
+}
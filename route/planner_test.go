@@ -0,0 +1,131 @@
+package route
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/femot/pgoapi-go/api"
+	"github.com/femot/pgoapi-go/export"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+var start = &api.Location{Lat: 52.5200, Lon: 13.4050}
+
+func fortAt(id string, l *api.Location) *protos.FortData {
+	return &protos.FortData{
+		Id:        id,
+		Latitude:  l.Lat,
+		Longitude: l.Lon,
+		Enabled:   true,
+		Type:      protos.FortType_CHECKPOINT,
+	}
+}
+
+func square() []*protos.FortData {
+	// Corners of a square given in an order that crosses itself
+	return []*protos.FortData{
+		fortAt("north", start.Destination(0, 100)),
+		fortAt("south", start.Destination(0, 100).Destination(90, 100).Destination(180, 200)),
+		fortAt("east", start.Destination(0, 100).Destination(90, 100)),
+		fortAt("west", start.Destination(180, 100)),
+	}
+}
+
+func TestPlanVisitsAllForts(t *testing.T) {
+	r := NewPlanner(0, 0).Plan(start, square(), time.Now())
+	if len(r.Stops) != 4 {
+		t.Fatalf("expected 4 stops, got %d", len(r.Stops))
+	}
+
+	// The shortest loop walks around the square without crossing itself
+	if r.Length > 700 {
+		t.Errorf("expected a loop of at most 700m, got %f", r.Length)
+	}
+
+	locations := r.Locations()
+	if len(locations) != 6 || locations[0] != start || locations[5] != start {
+		t.Errorf("expected the locations to start and end at the start, got %v", locations)
+	}
+	if r.Stops[3].Distance >= r.Length {
+		t.Errorf("expected the last stop to be reached before the end of the loop")
+	}
+}
+
+func TestPlanMaxLength(t *testing.T) {
+	r := NewPlanner(250, 0).Plan(start, square(), time.Now())
+	if len(r.Stops) == 0 || len(r.Stops) == 4 {
+		t.Fatalf("expected some forts to be left out, got %d stops", len(r.Stops))
+	}
+	if r.Length > 250 {
+		t.Errorf("expected a loop of at most 250m, got %f", r.Length)
+	}
+}
+
+func TestPlanCooldown(t *testing.T) {
+	now := time.Unix(1000, 0)
+	forts := square()
+	forts[0].CooldownCompleteTimestampMs = now.Add(time.Hour).UnixNano() / int64(time.Millisecond)
+
+	r := NewPlanner(0, 0).Plan(start, forts, now)
+	for _, stop := range r.Stops {
+		if stop.Fort.Id == "north" {
+			t.Errorf("expected the fort on cooldown to be left out")
+		}
+	}
+
+	// Walking slowly the fort is off cooldown by the time it is reached
+	forts[0].CooldownCompleteTimestampMs = now.Add(time.Minute).UnixNano() / int64(time.Millisecond)
+	r = NewPlanner(0, 1).Plan(start, forts, now)
+	if len(r.Stops) != 4 {
+		t.Fatalf("expected 4 stops, got %d", len(r.Stops))
+	}
+	for _, stop := range r.Stops {
+		if stop.Arrival.IsZero() {
+			t.Errorf("expected an arrival time for every stop")
+		}
+	}
+}
+
+func TestPokestops(t *testing.T) {
+	gym := fortAt("gym", start)
+	gym.Type = protos.FortType_GYM
+	disabled := fortAt("disabled", start)
+	disabled.Enabled = false
+
+	forts := Pokestops(&protos.GetMapObjectsResponse{
+		MapCells: []*protos.MapCell{{Forts: []*protos.FortData{gym, disabled, fortAt("stop", start)}}},
+	})
+	if len(forts) != 1 || forts[0].Id != "stop" {
+		t.Errorf("expected only the enabled Pokéstop, got %v", forts)
+	}
+}
+
+func TestExport(t *testing.T) {
+	r := NewPlanner(0, 0).Plan(start, square(), time.Now())
+
+	b, err := r.GPX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc export.GPXDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Waypoints) != 4 || len(doc.Routes) != 1 || len(doc.Routes[0].Points) != 6 {
+		t.Errorf("expected 4 waypoints and a route, got %d waypoints and %v", len(doc.Waypoints), doc.Routes)
+	}
+
+	b, err = r.GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection export.GeoJSONFeatureCollection
+	if err := json.Unmarshal(b, &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 5 || collection.Features[0].Geometry.Type != "LineString" {
+		t.Errorf("expected a line string and 4 points, got %v", collection.Features)
+	}
+}
@@ -0,0 +1,51 @@
+// Package route plans walking loops visiting Pokéstops
+package route
+
+import (
+	"time"
+
+	"github.com/femot/pgoapi-go/api"
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// Stop is a fort visited by a route
+type Stop struct {
+	Fort     *protos.FortData
+	Location *api.Location
+	// Distance is how far along the route the stop is reached, in metres
+	Distance float64
+	// Arrival is the estimated time the stop is reached, or the zero time when no speed is known
+	Arrival time.Time
+}
+
+// Route is a loop from a start location visiting stops and returning to the start
+type Route struct {
+	Start *api.Location
+	Stops []*Stop
+	// Length is the total length of the loop in metres
+	Length float64
+}
+
+// Locations returns the waypoints of the loop, starting and ending at the start location,
+// to be followed by a walker
+func (r *Route) Locations() []*api.Location {
+	locations := make([]*api.Location, 0, len(r.Stops)+2)
+	locations = append(locations, r.Start)
+	for _, stop := range r.Stops {
+		locations = append(locations, stop.Location)
+	}
+	return append(locations, r.Start)
+}
+
+// Pokestops returns the enabled Pokéstops from the map cells
+func Pokestops(mapObjects *protos.GetMapObjectsResponse) []*protos.FortData {
+	forts := make([]*protos.FortData, 0)
+	for _, cell := range mapObjects.GetMapCells() {
+		for _, fort := range cell.Forts {
+			if fort.Type == protos.FortType_CHECKPOINT && fort.Enabled {
+				forts = append(forts, fort)
+			}
+		}
+	}
+	return forts
+}
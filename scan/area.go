@@ -0,0 +1,77 @@
+// Package scan plans the locations to announce from in order to scan a whole area of the map
+package scan
+
+import (
+	"errors"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+const earthRadiusInMeters = 6378100
+
+// ErrInvalidArea happens when an area cannot describe a region on the map
+var ErrInvalidArea = errors.New("scan: The area is not a valid region")
+
+// Area is a common interface for regions of the map that can be scanned
+type Area interface {
+	Region() (s2.Region, error)
+}
+
+// Circle is the area within a radius in metres of a center location
+type Circle struct {
+	Center api.Location
+	Radius float64
+}
+
+// Region returns the circle as a spherical cap
+func (c *Circle) Region() (s2.Region, error) {
+	if c.Radius <= 0 || !c.Center.IsValid() {
+		return nil, ErrInvalidArea
+	}
+	center := s2.PointFromLatLng(s2.LatLngFromDegrees(c.Center.Lat, c.Center.Lon))
+	return s2.CapFromCenterAngle(center, s1.Angle(c.Radius/earthRadiusInMeters)), nil
+}
+
+// Polygon is the area enclosed by a ring of vertices, the ring may be open or closed
+type Polygon []api.Location
+
+// Region returns the polygon as a loop, regardless of the winding order of the vertices
+func (p Polygon) Region() (s2.Region, error) {
+	vertices := p
+	if len(vertices) > 1 && vertices[0].Lat == vertices[len(vertices)-1].Lat && vertices[0].Lon == vertices[len(vertices)-1].Lon {
+		vertices = vertices[:len(vertices)-1]
+	}
+	if len(vertices) < 3 {
+		return nil, ErrInvalidArea
+	}
+
+	points := make([]s2.Point, len(vertices))
+	for i, vertex := range vertices {
+		if !vertex.IsValid() {
+			return nil, ErrInvalidArea
+		}
+		points[i] = s2.PointFromLatLng(s2.LatLngFromDegrees(vertex.Lat, vertex.Lon))
+	}
+
+	// Loops enclose the area to the left of the edges, so clockwise rings have to be reversed
+	if vertices.signedArea() < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	return s2.LoopFromPoints(points), nil
+}
+
+// signedArea is positive for counter clockwise rings using the shoelace formula
+func (p Polygon) signedArea() float64 {
+	area := 0.0
+	for i := range p {
+		j := (i + 1) % len(p)
+		area += p[i].Lon*p[j].Lat - p[j].Lon*p[i].Lat
+	}
+	return area / 2
+}
@@ -0,0 +1,51 @@
+package scan
+
+import (
+	"encoding/json"
+
+	"github.com/golang/geo/s2"
+
+	"github.com/femot/pgoapi-go/export"
+)
+
+// GeoJSON returns the plan as a feature collection of the scan locations and the cells of the area,
+// with the coverage statistics as properties of the collection
+func (p *Plan) GeoJSON() ([]byte, error) {
+	collection := export.NewGeoJSONFeatureCollection()
+	collection.Properties = map[string]interface{}{
+		"locations":       len(p.Locations),
+		"cells":           len(p.Cells),
+		"requested_cells": p.RequestedCells,
+		"redundancy":      p.Redundancy(),
+	}
+
+	for i, location := range p.Locations {
+		collection.Add(&export.GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: export.GeoJSONPosition(location.Lat, location.Lon),
+		}, map[string]interface{}{
+			"type":  "scan",
+			"order": i,
+		})
+	}
+
+	for _, id := range p.Cells {
+		cell := s2.CellFromCellID(s2.CellID(id))
+		ring := make([][]float64, 0, 5)
+		for k := 0; k < 4; k++ {
+			vertex := s2.LatLngFromPoint(cell.Vertex(k))
+			ring = append(ring, export.GeoJSONPosition(vertex.Lat.Degrees(), vertex.Lng.Degrees()))
+		}
+		ring = append(ring, ring[0])
+
+		collection.Add(&export.GeoJSONGeometry{
+			Type:        "Polygon",
+			Coordinates: [][][]float64{ring},
+		}, map[string]interface{}{
+			"type":    "cell",
+			"cell_id": s2.CellID(id).ToToken(),
+		})
+	}
+
+	return json.Marshal(collection)
+}
@@ -0,0 +1,180 @@
+package scan
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/golang/geo/s2"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+const defaultLevel = 15
+const defaultMaxCells = 10000
+
+// ErrAreaTooLarge happens when an area is divided in to more cells than the planner allows
+var ErrAreaTooLarge = errors.New("scan: The area contains too many cells")
+
+// Planner chooses scan locations so that the cells requested from them cover a whole area
+type Planner struct {
+	// Coverage decides which cells are requested from a scan location, like the session does when announcing
+	Coverage api.Coverage
+	// Level is the level of the cells the area is divided in to, it has to match the coverage
+	Level int
+	// MaxCells is the maximum amount of cells the area may be divided in to
+	MaxCells int
+}
+
+// NewPlanner constructs a planner for the default coverage of a session
+func NewPlanner() *Planner {
+	return &Planner{
+		Coverage: &api.DefaultCoverage{},
+		Level:    defaultLevel,
+		MaxCells: defaultMaxCells,
+	}
+}
+
+// Plan is an ordered list of scan locations that together cover an area
+type Plan struct {
+	Locations []*api.Location
+	// Cells are the cells of the area
+	Cells api.CellIDs
+	// RequestedCells is the total amount of cells requested when announcing from all locations
+	RequestedCells int
+}
+
+// Redundancy returns how many cells are requested for every cell of the area, where 1 means no overlap at all
+func (p *Plan) Redundancy() float64 {
+	if len(p.Cells) == 0 {
+		return 0
+	}
+	return float64(p.RequestedCells) / float64(len(p.Cells))
+}
+
+// Plan divides the area in to cells and greedily picks the scan locations covering the most uncovered cells
+func (p *Planner) Plan(area Area) (*Plan, error) {
+	region, err := area.Region()
+	if err != nil {
+		return nil, err
+	}
+
+	covering, err := p.cells(region)
+	if err != nil {
+		return nil, err
+	}
+
+	uncovered := make(map[s2.CellID]bool, len(covering))
+	for _, cellID := range covering {
+		uncovered[cellID] = true
+	}
+
+	// Any cell whose footprint reaches a cell of the area is a candidate scan location
+	footprints := make(map[s2.CellID][]s2.CellID)
+	for _, cellID := range covering {
+		for _, candidate := range p.footprint(cellID) {
+			if _, ok := footprints[candidate]; !ok {
+				footprints[candidate] = p.footprint(candidate)
+			}
+		}
+	}
+	candidates := make([]s2.CellID, 0, len(footprints))
+	for candidate := range footprints {
+		candidates = append(candidates, candidate)
+	}
+	sort.Sort(cellIDs(candidates))
+
+	plan := &Plan{Cells: make(api.CellIDs, len(covering))}
+	for i, cellID := range covering {
+		plan.Cells[i] = uint64(cellID)
+	}
+
+	chosen := make([]s2.CellID, 0)
+	for len(uncovered) > 0 {
+		best, bestCount := s2.CellID(0), 0
+		for _, candidate := range candidates {
+			count := 0
+			for _, cellID := range footprints[candidate] {
+				if uncovered[cellID] {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = candidate, count
+			}
+		}
+		if bestCount == 0 {
+			// The coverage does not reach every cell, so the rest of the area cannot be covered
+			break
+		}
+
+		chosen = append(chosen, best)
+		plan.RequestedCells += len(footprints[best])
+		for _, cellID := range footprints[best] {
+			delete(uncovered, cellID)
+		}
+	}
+
+	// Cell ids follow a space filling curve, so sorting them keeps consecutive locations close together
+	sort.Sort(cellIDs(chosen))
+	for _, cellID := range chosen {
+		plan.Locations = append(plan.Locations, p.location(cellID))
+	}
+
+	return plan, nil
+}
+
+// cells divides the region in to cells of the planner's level, descending from a coarse covering so that
+// an area with more than MaxCells cells is rejected without enumerating all of its cells
+func (p *Planner) cells(region s2.Region) ([]s2.CellID, error) {
+	covering := make([]s2.CellID, 0)
+	var add func(cellID s2.CellID) error
+	add = func(cellID s2.CellID) error {
+		if !region.IntersectsCell(s2.CellFromCellID(cellID)) {
+			return nil
+		}
+		if cellID.Level() >= p.Level {
+			if len(covering) >= p.MaxCells {
+				return ErrAreaTooLarge
+			}
+			covering = append(covering, cellID)
+			return nil
+		}
+		for _, child := range cellID.Children() {
+			if err := add(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	coverer := &s2.RegionCoverer{MaxLevel: p.Level, MaxCells: 8}
+	for _, cellID := range coverer.Covering(region) {
+		if err := add(cellID); err != nil {
+			return nil, err
+		}
+	}
+	return covering, nil
+}
+
+func (p *Planner) location(cellID s2.CellID) *api.Location {
+	center := cellID.LatLng()
+	return &api.Location{
+		Lat: center.Lat.Degrees(),
+		Lon: center.Lng.Degrees(),
+	}
+}
+
+func (p *Planner) footprint(cellID s2.CellID) []s2.CellID {
+	ids := p.Coverage.CellIDs(p.location(cellID))
+	footprint := make([]s2.CellID, len(ids))
+	for i, id := range ids {
+		footprint[i] = s2.CellID(id)
+	}
+	return footprint
+}
+
+type cellIDs []s2.CellID
+
+func (a cellIDs) Len() int           { return len(a) }
+func (a cellIDs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a cellIDs) Less(i, j int) bool { return a[i] < a[j] }
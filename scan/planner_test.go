@@ -0,0 +1,112 @@
+package scan
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+func TestPlanCoversArea(t *testing.T) {
+	areas := map[string]Area{
+		"circle": &Circle{Center: api.Location{Lat: 59.3293, Lon: 18.0686}, Radius: 800},
+		"clockwise polygon": Polygon{
+			{Lat: 59.335, Lon: 18.060},
+			{Lat: 59.335, Lon: 18.080},
+			{Lat: 59.325, Lon: 18.080},
+			{Lat: 59.325, Lon: 18.060},
+			{Lat: 59.335, Lon: 18.060},
+		},
+		"counter clockwise polygon": Polygon{
+			{Lat: 59.325, Lon: 18.060},
+			{Lat: 59.325, Lon: 18.080},
+			{Lat: 59.335, Lon: 18.080},
+			{Lat: 59.335, Lon: 18.060},
+		},
+	}
+
+	for name, area := range areas {
+		plan, err := NewPlanner().Plan(area)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if len(plan.Cells) < 10 {
+			t.Errorf("%s: expected the area to span several cells, got %d", name, len(plan.Cells))
+		}
+
+		covered := make(map[uint64]bool)
+		for _, location := range plan.Locations {
+			for _, cellID := range location.GetCellIDs() {
+				covered[cellID] = true
+			}
+		}
+		for _, cellID := range plan.Cells {
+			if !covered[cellID] {
+				t.Errorf("%s: cell %d is not covered by any scan location", name, cellID)
+			}
+		}
+
+		// A perfect tiling of five cell footprints has no overlap, the greedy plan should come close
+		if plan.Redundancy() < 1 || plan.Redundancy() > 2.5 {
+			t.Errorf("%s: unexpected redundancy %.2f", name, plan.Redundancy())
+		}
+		if len(plan.Locations)*5 != plan.RequestedCells {
+			t.Errorf("%s: expected five requested cells per location", name)
+		}
+	}
+}
+
+func TestPlanInvalidArea(t *testing.T) {
+	areas := []Area{
+		Polygon{{Lat: 1, Lon: 1}, {Lat: 2, Lon: 2}},
+		Polygon{{Lat: 91, Lon: 1}, {Lat: 2, Lon: 2}, {Lat: 3, Lon: 1}},
+		&Circle{Center: api.Location{Lat: 1, Lon: 1}},
+	}
+	for _, area := range areas {
+		if _, err := NewPlanner().Plan(area); err != ErrInvalidArea {
+			t.Errorf("expected %v to be invalid, got %v", area, err)
+		}
+	}
+
+	planner := NewPlanner()
+	planner.MaxCells = 10
+	if _, err := planner.Plan(&Circle{Center: api.Location{Lat: 1, Lon: 1}, Radius: 5000}); err != ErrAreaTooLarge {
+		t.Errorf("expected area to be too large, got %v", err)
+	}
+
+	// A country sized area is rejected without dividing all of it in to cells
+	start := time.Now()
+	if _, err := NewPlanner().Plan(&Circle{Center: api.Location{Lat: 59.3293, Lon: 18.0686}, Radius: 500000}); err != ErrAreaTooLarge {
+		t.Errorf("expected a country to be too large, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected a large area to be rejected quickly, took %s", elapsed)
+	}
+}
+
+func TestPlanGeoJSON(t *testing.T) {
+	plan, err := NewPlanner().Plan(&Circle{Center: api.Location{Lat: 59.3293, Lon: 18.0686}, Radius: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := plan.GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type string
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != len(plan.Locations)+len(plan.Cells) {
+		t.Errorf("unexpected collection %s with %d features", collection.Type, len(collection.Features))
+	}
+}
@@ -0,0 +1,119 @@
+// Package spawns remembers spawn points across map scans and estimates when their Pokémon despawn
+package spawns
+
+import (
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// hour is the length of the cycle in which every spawn point spawns once
+const hour = int64(time.Hour / time.Millisecond)
+
+// Observation is a Pokémon seen at a spawn point
+type Observation struct {
+	TimestampMs int64
+	EncounterID uint64
+	PokemonID   protos.PokemonId
+	// TimeTillHiddenMs is the remaining time reported by the server, or zero when it was not known
+	TimeTillHiddenMs int32
+}
+
+// Estimate is the part of the hour in which a spawn point despawns,
+// as offsets from the start of every hour
+type Estimate struct {
+	Earliest time.Duration
+	Width    time.Duration
+}
+
+// Exact returns whether or not the despawn time is known to the millisecond
+func (e Estimate) Exact() bool {
+	return e.Width == 0
+}
+
+// Latest returns the latest offset from the start of the hour at which the spawn point despawns
+func (e Estimate) Latest() time.Duration {
+	return (e.Earliest + e.Width) % time.Hour
+}
+
+type arc struct {
+	start int64
+	width int64
+}
+
+func newArc(from, to int64) arc {
+	return arc{start: mod(from, hour), width: to - from}
+}
+
+func mod(a, b int64) int64 {
+	return ((a % b) + b) % b
+}
+
+// intersect returns the overlap of two parts of the hour, or false when they do not overlap
+func (a arc) intersect(b arc) (arc, bool) {
+	if d := mod(b.start-a.start, hour); d <= a.width {
+		return arc{start: b.start, width: minInt64(a.width-d, b.width)}, true
+	}
+	if d := mod(a.start-b.start, hour); d <= b.width {
+		return arc{start: a.start, width: minInt64(b.width-d, a.width)}, true
+	}
+	return arc{}, false
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// SpawnPoint is a location where Pokémon spawn once every hour
+type SpawnPoint struct {
+	ID        string
+	Latitude  float64
+	Longitude float64
+
+	// FirstSeenMs and LastSeenMs are the timestamps of the first and last scan including the spawn point
+	FirstSeenMs int64
+	LastSeenMs  int64
+	// Observations are the latest Pokémon seen at the spawn point, oldest first
+	Observations []Observation
+
+	estimate  arc
+	estimated bool
+
+	encounterID      uint64
+	encounterFirstMs int64
+}
+
+// Despawn returns the estimated part of the hour in which the spawn point despawns,
+// or false when no Pokémon have been seen at the spawn point yet
+func (sp *SpawnPoint) Despawn() (Estimate, bool) {
+	if !sp.estimated {
+		return Estimate{}, false
+	}
+	return Estimate{
+		Earliest: time.Duration(sp.estimate.start) * time.Millisecond,
+		Width:    time.Duration(sp.estimate.width) * time.Millisecond,
+	}, true
+}
+
+// narrow combines what is known about the despawn time with a new range of possible despawn timestamps,
+// starting over when they contradict each other as the spawn point has probably changed
+func (sp *SpawnPoint) narrow(fromMs, toMs int64) {
+	update := newArc(fromMs, toMs)
+	if sp.estimated {
+		if merged, ok := sp.estimate.intersect(update); ok {
+			update = merged
+		}
+	}
+	sp.estimate = update
+	sp.estimated = true
+}
+
+func (sp *SpawnPoint) clone() *SpawnPoint {
+	c := *sp
+	c.Observations = make([]Observation, len(sp.Observations))
+	copy(c.Observations, sp.Observations)
+	return &c
+}
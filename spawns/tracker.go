@@ -0,0 +1,208 @@
+package spawns
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// maxTimeTillHiddenMs is the longest remaining time the server reports reliably
+const maxTimeTillHiddenMs = 3600000
+
+// Tracker records the spawn points seen in map responses and the Pokémon observed at them
+type Tracker struct {
+	// SpawnDuration is how long a Pokémon stays after spawning
+	SpawnDuration time.Duration
+	// MaxObservations is how many observations are kept for every spawn point
+	MaxObservations int
+
+	mutex  sync.RWMutex
+	points map[string]*SpawnPoint
+}
+
+// NewTracker constructs a tracker for Pokémon that stay for 15 minutes
+func NewTracker() *Tracker {
+	return &Tracker{
+		SpawnDuration:   15 * time.Minute,
+		MaxObservations: 20,
+		points:          make(map[string]*SpawnPoint),
+	}
+}
+
+func spawnPointKey(latitude, longitude float64) string {
+	return fmt.Sprintf("%f,%f", latitude, longitude)
+}
+
+func (t *Tracker) spawnPoint(latitude, longitude float64, timestampMs int64) *SpawnPoint {
+	key := spawnPointKey(latitude, longitude)
+	sp, ok := t.points[key]
+	if !ok {
+		sp = &SpawnPoint{
+			Latitude:    latitude,
+			Longitude:   longitude,
+			FirstSeenMs: timestampMs,
+		}
+		t.points[key] = sp
+	}
+	if timestampMs > sp.LastSeenMs {
+		sp.LastSeenMs = timestampMs
+	}
+	return sp
+}
+
+// Update records the spawn points and Pokémon of a map response
+func (t *Tracker) Update(mapObjects *protos.GetMapObjectsResponse) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, cell := range mapObjects.GetMapCells() {
+		for _, spawnPoint := range cell.SpawnPoints {
+			t.spawnPoint(spawnPoint.Latitude, spawnPoint.Longitude, cell.CurrentTimestampMs)
+		}
+
+		for _, pokemon := range cell.WildPokemons {
+			timestampMs := pokemon.LastModifiedTimestampMs
+			if timestampMs == 0 {
+				timestampMs = cell.CurrentTimestampMs
+			}
+			sp := t.spawnPoint(pokemon.Latitude, pokemon.Longitude, timestampMs)
+			sp.ID = pokemon.SpawnPointId
+			t.observe(sp, Observation{
+				TimestampMs:      timestampMs,
+				EncounterID:      pokemon.EncounterId,
+				PokemonID:        pokemon.PokemonData.GetPokemonId(),
+				TimeTillHiddenMs: pokemon.TimeTillHiddenMs,
+			}, 0)
+		}
+
+		for _, pokemon := range cell.CatchablePokemons {
+			sp := t.spawnPoint(pokemon.Latitude, pokemon.Longitude, cell.CurrentTimestampMs)
+			sp.ID = pokemon.SpawnPointId
+			t.observe(sp, Observation{
+				TimestampMs: cell.CurrentTimestampMs,
+				EncounterID: pokemon.EncounterId,
+				PokemonID:   pokemon.PokemonId,
+			}, pokemon.ExpirationTimestampMs)
+		}
+	}
+}
+
+// observe narrows the despawn time of the spawn point using a Pokémon seen there,
+// which despawns at expirationMs when it is known
+func (t *Tracker) observe(sp *SpawnPoint, observation Observation, expirationMs int64) {
+	if observation.EncounterID != sp.encounterID {
+		sp.encounterID = observation.EncounterID
+		sp.encounterFirstMs = observation.TimestampMs
+	}
+	// The same Pokémon is usually reported as both wild and catchable, so only keep one observation per scan
+	if n := len(sp.Observations); n == 0 || sp.Observations[n-1].EncounterID != observation.EncounterID || sp.Observations[n-1].TimestampMs != observation.TimestampMs {
+		sp.Observations = append(sp.Observations, observation)
+		if t.MaxObservations > 0 && len(sp.Observations) > t.MaxObservations {
+			sp.Observations = sp.Observations[len(sp.Observations)-t.MaxObservations:]
+		}
+	}
+
+	if expirationMs <= 0 && observation.TimeTillHiddenMs > 0 && observation.TimeTillHiddenMs <= maxTimeTillHiddenMs {
+		expirationMs = observation.TimestampMs + int64(observation.TimeTillHiddenMs)
+	}
+	if expirationMs > 0 {
+		sp.narrow(expirationMs, expirationMs)
+		return
+	}
+
+	// Without a remaining time the Pokémon despawns after it was last seen,
+	// but no later than a spawn duration after it was first seen
+	latestMs := sp.encounterFirstMs + int64(t.SpawnDuration/time.Millisecond)
+	if latestMs > observation.TimestampMs {
+		sp.narrow(observation.TimestampMs, latestMs)
+	}
+}
+
+// SpawnPoint returns a copy of a spawn point by its coordinates
+func (t *Tracker) SpawnPoint(latitude, longitude float64) (*SpawnPoint, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	sp, ok := t.points[spawnPointKey(latitude, longitude)]
+	if !ok {
+		return nil, false
+	}
+	return sp.clone(), true
+}
+
+// SpawnPoints returns copies of all known spawn points
+func (t *Tracker) SpawnPoints() []*SpawnPoint {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	points := make([]*SpawnPoint, 0, len(t.points))
+	for _, sp := range t.points {
+		points = append(points, sp.clone())
+	}
+	sort.Sort(spawnPointsByLocation(points))
+	return points
+}
+
+// Window is a period of time in which a Pokémon may be present at a spawn point
+type Window struct {
+	SpawnPoint *SpawnPoint
+	Start      time.Time
+	End        time.Time
+}
+
+// NextWindow returns the next period in which a Pokémon may be present at the spawn point,
+// which is the current one when it has not ended yet
+func (t *Tracker) NextWindow(sp *SpawnPoint, now time.Time) (Window, bool) {
+	estimate, ok := sp.Despawn()
+	if !ok {
+		return Window{}, false
+	}
+
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	endMs := nowMs - mod(nowMs, hour) + int64(estimate.Latest()/time.Millisecond)
+	for endMs <= nowMs {
+		endMs += hour
+	}
+	for endMs-hour > nowMs {
+		endMs -= hour
+	}
+	startMs := endMs - int64((estimate.Width+t.SpawnDuration)/time.Millisecond)
+
+	return Window{
+		SpawnPoint: sp,
+		Start:      time.Unix(0, startMs*int64(time.Millisecond)),
+		End:        time.Unix(0, endMs*int64(time.Millisecond)),
+	}, true
+}
+
+// NextWindows returns the next active period of every spawn point with an estimate, ordered by start
+func (t *Tracker) NextWindows(now time.Time) []Window {
+	windows := make([]Window, 0)
+	for _, sp := range t.SpawnPoints() {
+		if window, ok := t.NextWindow(sp, now); ok {
+			windows = append(windows, window)
+		}
+	}
+	sort.Sort(windowsByStart(windows))
+	return windows
+}
+
+type spawnPointsByLocation []*SpawnPoint
+
+func (a spawnPointsByLocation) Len() int      { return len(a) }
+func (a spawnPointsByLocation) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a spawnPointsByLocation) Less(i, j int) bool {
+	if a[i].Latitude != a[j].Latitude {
+		return a[i].Latitude < a[j].Latitude
+	}
+	return a[i].Longitude < a[j].Longitude
+}
+
+type windowsByStart []Window
+
+func (a windowsByStart) Len() int           { return len(a) }
+func (a windowsByStart) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a windowsByStart) Less(i, j int) bool { return a[i].Start.Before(a[j].Start) }
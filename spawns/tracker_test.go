@@ -0,0 +1,112 @@
+package spawns
+
+import (
+	"testing"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+)
+
+// base is the start of an hour
+var base = time.Date(2016, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func ms(d time.Duration) int64 {
+	return base.Add(d).UnixNano() / int64(time.Millisecond)
+}
+
+func scan(at time.Duration, pokemon ...*protos.WildPokemon) *protos.GetMapObjectsResponse {
+	return &protos.GetMapObjectsResponse{
+		MapCells: []*protos.MapCell{{
+			CurrentTimestampMs: ms(at),
+			SpawnPoints:        []*protos.SpawnPoint{{Latitude: 52.52, Longitude: 13.405}},
+			WildPokemons:       pokemon,
+		}},
+	}
+}
+
+func wild(encounterID uint64, at time.Duration, timeTillHidden time.Duration) *protos.WildPokemon {
+	return &protos.WildPokemon{
+		EncounterId:             encounterID,
+		Latitude:                52.52,
+		Longitude:               13.405,
+		SpawnPointId:            "47bd",
+		LastModifiedTimestampMs: ms(at),
+		TimeTillHiddenMs:        int32(timeTillHidden / time.Millisecond),
+		PokemonData:             &protos.PokemonData{PokemonId: protos.PokemonId_PIDGEY},
+	}
+}
+
+func TestExactDespawn(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Update(scan(10*time.Minute, wild(1, 10*time.Minute, 80*time.Second)))
+
+	sp, ok := tracker.SpawnPoint(52.52, 13.405)
+	if !ok {
+		t.Fatal("expected the spawn point to be known")
+	}
+	if sp.ID != "47bd" || len(sp.Observations) != 1 {
+		t.Errorf("expected the spawn point with one observation, got %v", sp)
+	}
+	estimate, ok := sp.Despawn()
+	if !ok || !estimate.Exact() || estimate.Earliest != 11*time.Minute+20*time.Second {
+		t.Errorf("expected the spawn point to despawn at 11:20, got %v", estimate)
+	}
+
+	window, ok := tracker.NextWindow(sp, base.Add(30*time.Minute))
+	if !ok {
+		t.Fatal("expected a window")
+	}
+	if !window.End.Equal(base.Add(time.Hour+11*time.Minute+20*time.Second)) || !window.Start.Equal(window.End.Add(-15*time.Minute)) {
+		t.Errorf("expected the next window in the next hour, got %v - %v", window.Start, window.End)
+	}
+
+	// During the window the current one is returned
+	window, _ = tracker.NextWindow(sp, base.Add(5*time.Minute))
+	if !window.End.Equal(base.Add(11*time.Minute + 20*time.Second)) {
+		t.Errorf("expected the current window, got %v - %v", window.Start, window.End)
+	}
+}
+
+func TestEstimatedDespawn(t *testing.T) {
+	tracker := NewTracker()
+
+	// Nothing is known without Pokémon
+	tracker.Update(scan(0))
+	sp, _ := tracker.SpawnPoint(52.52, 13.405)
+	if _, ok := sp.Despawn(); ok {
+		t.Errorf("expected no estimate without observations")
+	}
+
+	// Seen at 20 and 30 minutes past the hour, so it despawns between 30 and 35 minutes past
+	tracker.Update(scan(20*time.Minute, wild(1, 20*time.Minute, 0)))
+	tracker.Update(scan(30*time.Minute, wild(1, 30*time.Minute, 0)))
+	sp, _ = tracker.SpawnPoint(52.52, 13.405)
+	estimate, _ := sp.Despawn()
+	if estimate.Earliest != 30*time.Minute || estimate.Width != 5*time.Minute {
+		t.Errorf("expected a despawn between 30 and 35 minutes past, got %v", estimate)
+	}
+
+	// The next hour narrows it down further
+	tracker.Update(scan(time.Hour+33*time.Minute, wild(2, time.Hour+33*time.Minute, 0)))
+	sp, _ = tracker.SpawnPoint(52.52, 13.405)
+	estimate, _ = sp.Despawn()
+	if estimate.Earliest != 33*time.Minute || estimate.Width != 2*time.Minute {
+		t.Errorf("expected a despawn between 33 and 35 minutes past, got %v", estimate)
+	}
+	if len(sp.Observations) != 3 {
+		t.Errorf("expected 3 observations, got %d", len(sp.Observations))
+	}
+}
+
+func TestEstimateAcrossHour(t *testing.T) {
+	a := newArc(ms(55*time.Minute), ms(70*time.Minute))
+	b := newArc(ms(65*time.Minute), ms(80*time.Minute))
+	merged, ok := a.intersect(b)
+	if !ok || merged.start != int64(5*time.Minute/time.Millisecond) || merged.width != int64(5*time.Minute/time.Millisecond) {
+		t.Errorf("expected the estimate to wrap around the hour, got %v", merged)
+	}
+
+	if _, ok := a.intersect(newArc(ms(20*time.Minute), ms(25*time.Minute))); ok {
+		t.Errorf("expected disjoint estimates not to intersect")
+	}
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Sighting is a Pokémon seen on the map, kept once per encounter
+type Sighting struct {
+	EncounterID  uint64           `json:"encounter_id"`
+	PokemonID    protos.PokemonId `json:"pokemon_id"`
+	Latitude     float64          `json:"latitude"`
+	Longitude    float64          `json:"longitude"`
+	SpawnPointID string           `json:"spawn_point_id,omitempty"`
+	FirstSeenMs  int64            `json:"first_seen_ms"`
+	LastSeenMs   int64            `json:"last_seen_ms"`
+	// ExpiresMs is when the Pokémon despawns, or zero when it is not known
+	ExpiresMs int64 `json:"expires_ms,omitempty"`
+}
+
+// GetLatitude returns the latitude of the sighting
+func (s *Sighting) GetLatitude() float64 { return s.Latitude }
+
+// GetLongitude returns the longitude of the sighting
+func (s *Sighting) GetLongitude() float64 { return s.Longitude }
+
+// SpawnPoint is a location where Pokémon spawn
+type SpawnPoint struct {
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	FirstSeenMs int64   `json:"first_seen_ms"`
+	LastSeenMs  int64   `json:"last_seen_ms"`
+}
+
+// GetLatitude returns the latitude of the spawn point
+func (s *SpawnPoint) GetLatitude() float64 { return s.Latitude }
+
+// GetLongitude returns the longitude of the spawn point
+func (s *SpawnPoint) GetLongitude() float64 { return s.Longitude }
+
+// GymSnapshot is the state of a gym at a time
+type GymSnapshot struct {
+	Time time.Time
+	Fort *protos.FortData
+}
+
+// SaveMapObjects saves the forts, spawn points and Pokémon of a map response seen at a time
+//
+// Forts are only replaced by versions that are at least as recent, and a gym snapshot is added to
+// the history of a gym whenever its team, prestige or guard changed.
+func (s *Store) SaveMapObjects(mapObjects *protos.GetMapObjectsResponse, seen time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, cell := range mapObjects.GetMapCells() {
+			seenMs := cell.CurrentTimestampMs
+			if seenMs == 0 {
+				seenMs = timestampMs(seen)
+			}
+
+			for _, fort := range cell.Forts {
+				if err := saveFort(tx, fort, seenMs); err != nil {
+					return err
+				}
+			}
+			for _, spawnPoints := range [][]*protos.SpawnPoint{cell.SpawnPoints, cell.DecimatedSpawnPoints} {
+				for _, spawnPoint := range spawnPoints {
+					if err := saveSpawnPoint(tx, spawnPoint, seenMs); err != nil {
+						return err
+					}
+				}
+			}
+			for _, pokemon := range cell.WildPokemons {
+				sighting := &Sighting{
+					EncounterID:  pokemon.EncounterId,
+					PokemonID:    pokemon.PokemonData.GetPokemonId(),
+					Latitude:     pokemon.Latitude,
+					Longitude:    pokemon.Longitude,
+					SpawnPointID: pokemon.SpawnPointId,
+				}
+				if pokemon.TimeTillHiddenMs > 0 {
+					sighting.ExpiresMs = pokemon.LastModifiedTimestampMs + int64(pokemon.TimeTillHiddenMs)
+				}
+				if err := saveSighting(tx, sighting, seenMs); err != nil {
+					return err
+				}
+			}
+			for _, pokemon := range cell.CatchablePokemons {
+				sighting := &Sighting{
+					EncounterID:  pokemon.EncounterId,
+					PokemonID:    pokemon.PokemonId,
+					Latitude:     pokemon.Latitude,
+					Longitude:    pokemon.Longitude,
+					SpawnPointID: pokemon.SpawnPointId,
+				}
+				if pokemon.ExpirationTimestampMs > 0 {
+					sighting.ExpiresMs = pokemon.ExpirationTimestampMs
+				}
+				if err := saveSighting(tx, sighting, seenMs); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func saveFort(tx *bolt.Tx, fort *protos.FortData, seenMs int64) error {
+	forts := tx.Bucket(fortsBucket)
+	key := []byte(fort.Id)
+
+	var previous *protos.FortData
+	if b := forts.Get(key); b != nil {
+		previous = &protos.FortData{}
+		if err := proto.Unmarshal(b, previous); err != nil {
+			return err
+		}
+		if previous.LastModifiedTimestampMs > fort.LastModifiedTimestampMs {
+			return nil
+		}
+	}
+
+	b, err := proto.Marshal(fort)
+	if err != nil {
+		return err
+	}
+	if err := forts.Put(key, b); err != nil {
+		return err
+	}
+
+	if fort.Type != protos.FortType_GYM {
+		return nil
+	}
+	if previous != nil && previous.OwnedByTeam == fort.OwnedByTeam && previous.GymPoints == fort.GymPoints && previous.GuardPokemonId == fort.GuardPokemonId {
+		return nil
+	}
+	history, err := tx.Bucket(gymHistoryBucket).CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+	// The sequence keeps changes seen at the same time apart
+	sequence, err := history.NextSequence()
+	if err != nil {
+		return err
+	}
+	return history.Put(append(itob(uint64(seenMs)), itob(sequence)...), b)
+}
+
+func spawnPointKey(latitude, longitude float64) []byte {
+	return []byte(fmt.Sprintf("%f,%f", latitude, longitude))
+}
+
+// spawnPointCellKey indexes a spawn point by the leaf cell of its location followed by its key
+func spawnPointCellKey(spawnPoint *protos.SpawnPoint, key []byte) []byte {
+	cellID := s2.CellIDFromLatLng(s2.LatLngFromDegrees(spawnPoint.Latitude, spawnPoint.Longitude))
+	return append(itob(uint64(cellID)), key...)
+}
+
+func saveSpawnPoint(tx *bolt.Tx, spawnPoint *protos.SpawnPoint, seenMs int64) error {
+	spawnPoints := tx.Bucket(spawnPointsBucket)
+	key := spawnPointKey(spawnPoint.Latitude, spawnPoint.Longitude)
+
+	// Spawn points do not move, so they are indexed once when first seen
+	if spawnPoints.Get(key) == nil {
+		if err := tx.Bucket(spawnPointsByCellBucket).Put(spawnPointCellKey(spawnPoint, key), key); err != nil {
+			return err
+		}
+	}
+
+	record := &SpawnPoint{
+		Latitude:    spawnPoint.Latitude,
+		Longitude:   spawnPoint.Longitude,
+		FirstSeenMs: seenMs,
+		LastSeenMs:  seenMs,
+	}
+	if b := spawnPoints.Get(key); b != nil {
+		if err := json.Unmarshal(b, record); err != nil {
+			return err
+		}
+		if seenMs < record.FirstSeenMs {
+			record.FirstSeenMs = seenMs
+		}
+		if seenMs > record.LastSeenMs {
+			record.LastSeenMs = seenMs
+		}
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return spawnPoints.Put(key, b)
+}
+
+// sightingCellKey indexes a sighting by the leaf cell of its location followed by its encounter id,
+// so that the sightings within a cell of any level are stored next to each other
+func sightingCellKey(sighting *Sighting) []byte {
+	cellID := s2.CellIDFromLatLng(s2.LatLngFromDegrees(sighting.Latitude, sighting.Longitude))
+	return append(itob(uint64(cellID)), itob(sighting.EncounterID)...)
+}
+
+func saveSighting(tx *bolt.Tx, sighting *Sighting, seenMs int64) error {
+	sightings := tx.Bucket(sightingsBucket)
+	key := itob(sighting.EncounterID)
+
+	sighting.FirstSeenMs = seenMs
+	sighting.LastSeenMs = seenMs
+	if b := sightings.Get(key); b != nil {
+		previous := &Sighting{}
+		if err := json.Unmarshal(b, previous); err != nil {
+			return err
+		}
+		if previous.FirstSeenMs < sighting.FirstSeenMs {
+			sighting.FirstSeenMs = previous.FirstSeenMs
+		}
+		if previous.LastSeenMs > sighting.LastSeenMs {
+			sighting.LastSeenMs = previous.LastSeenMs
+		}
+		if sighting.ExpiresMs == 0 {
+			sighting.ExpiresMs = previous.ExpiresMs
+		}
+		if sighting.SpawnPointID == "" {
+			sighting.SpawnPointID = previous.SpawnPointID
+		}
+		if !bytes.Equal(sightingCellKey(previous), sightingCellKey(sighting)) {
+			if err := tx.Bucket(sightingsByCellBucket).Delete(sightingCellKey(previous)); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := json.Marshal(sighting)
+	if err != nil {
+		return err
+	}
+	if err := sightings.Put(key, b); err != nil {
+		return err
+	}
+	return tx.Bucket(sightingsByCellBucket).Put(sightingCellKey(sighting), key)
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PlayerSnapshot is the profile of a player at a time
+type PlayerSnapshot struct {
+	Time   time.Time
+	Player *protos.PlayerData
+}
+
+// SavePlayer adds a snapshot of the player profile to the history of the account,
+// snapshots of responses without an account id are not saved as they cannot be told apart by account
+func (s *Store) SavePlayer(accountID string, player *protos.GetPlayerResponse, seen time.Time) error {
+	if player.PlayerData == nil {
+		return nil
+	}
+	if accountID == "" {
+		return ErrNoAccountID
+	}
+
+	b, err := proto.Marshal(player.PlayerData)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		history, err := tx.Bucket(playersBucket).CreateBucketIfNotExists([]byte(accountID))
+		if err != nil {
+			return err
+		}
+		// The sequence keeps snapshots taken at the same time apart
+		sequence, err := history.NextSequence()
+		if err != nil {
+			return err
+		}
+		return history.Put(append(itob(uint64(timestampMs(seen))), itob(sequence)...), b)
+	})
+}
+
+// PlayerHistory returns the snapshots of an account taken in a time range, oldest first
+func (s *Store) PlayerHistory(accountID string, from, to time.Time) ([]*PlayerSnapshot, error) {
+	snapshots := make([]*PlayerSnapshot, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(playersBucket).Bucket([]byte(accountID))
+		if history == nil {
+			return ErrNotFound
+		}
+		return scanTimeRange(history, from, to, func(t time.Time, v []byte) error {
+			player := &protos.PlayerData{}
+			if err := proto.Unmarshal(v, player); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, &PlayerSnapshot{Time: t, Player: player})
+			return nil
+		})
+	})
+	return snapshots, err
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/golang/geo/r1"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// maxQueryCells is how many cells a bounding box is covered with when querying the cell index
+const maxQueryCells = 16
+
+// Fort returns the most recent version of a fort
+func (s *Store) Fort(id string) (*protos.FortData, error) {
+	fort := &protos.FortData{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(fortsBucket).Get([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		return proto.Unmarshal(b, fort)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fort, nil
+}
+
+// GymHistory returns the changes of a gym seen in a time range, oldest first
+func (s *Store) GymHistory(id string, from, to time.Time) ([]*GymSnapshot, error) {
+	snapshots := make([]*GymSnapshot, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(gymHistoryBucket).Bucket([]byte(id))
+		if history == nil {
+			return ErrNotFound
+		}
+		return scanTimeRange(history, from, to, func(t time.Time, v []byte) error {
+			fort := &protos.FortData{}
+			if err := proto.Unmarshal(v, fort); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, &GymSnapshot{Time: t, Fort: fort})
+			return nil
+		})
+	})
+	return snapshots, err
+}
+
+// scanTimeRange calls f for every value of a bucket keyed by timestamp within the time range,
+// keys may carry more bytes after the timestamp
+func scanTimeRange(bucket *bolt.Bucket, from, to time.Time, f func(time.Time, []byte) error) error {
+	c := bucket.Cursor()
+	max := itob(uint64(timestampMs(to)))
+	for k, v := c.Seek(itob(uint64(timestampMs(from)))); k != nil && bytes.Compare(k[:8], max) <= 0; k, v = c.Next() {
+		if err := f(timeFromMs(int64(binary.BigEndian.Uint64(k))), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boxRegion(box api.BoundingBox) s2.Rect {
+	return s2.Rect{
+		Lat: r1.Interval{Lo: (s1.Angle(box.MinLat) * s1.Degree).Radians(), Hi: (s1.Angle(box.MaxLat) * s1.Degree).Radians()},
+		// Longitude intervals with a start greater than the end wrap around the antimeridian like the box
+		Lng: s1.IntervalFromEndpoints((s1.Angle(box.MinLon) * s1.Degree).Radians(), (s1.Angle(box.MaxLon) * s1.Degree).Radians()),
+	}
+}
+
+// Sightings returns the Pokémon seen within the box at any time in the time range, ordered by when they were first seen
+func (s *Store) Sightings(box api.BoundingBox, from, to time.Time) ([]*Sighting, error) {
+	fromMs, toMs := timestampMs(from), timestampMs(to)
+	coverer := &s2.RegionCoverer{MaxLevel: 30, MaxCells: maxQueryCells}
+	covering := coverer.Covering(boxRegion(box))
+
+	sightings := make([]*Sighting, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		index := tx.Bucket(sightingsByCellBucket).Cursor()
+		records := tx.Bucket(sightingsBucket)
+
+		for _, cellID := range covering {
+			min, max := itob(uint64(cellID.RangeMin())), itob(uint64(cellID.RangeMax()))
+			for k, v := index.Seek(min); k != nil && bytes.Compare(k[:8], max) <= 0; k, v = index.Next() {
+				b := records.Get(v)
+				if b == nil {
+					continue
+				}
+				sighting := &Sighting{}
+				if err := json.Unmarshal(b, sighting); err != nil {
+					return err
+				}
+				if sighting.FirstSeenMs > toMs || sighting.LastSeenMs < fromMs || !box.Contains(sighting) {
+					continue
+				}
+				sightings = append(sightings, sighting)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sightingsByFirstSeen(sightings))
+	return sightings, nil
+}
+
+// SpawnPoints returns the spawn points within the box
+func (s *Store) SpawnPoints(box api.BoundingBox) ([]*SpawnPoint, error) {
+	coverer := &s2.RegionCoverer{MaxLevel: 30, MaxCells: maxQueryCells}
+	covering := coverer.Covering(boxRegion(box))
+
+	spawnPoints := make([]*SpawnPoint, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		index := tx.Bucket(spawnPointsByCellBucket).Cursor()
+		records := tx.Bucket(spawnPointsBucket)
+
+		for _, cellID := range covering {
+			min, max := itob(uint64(cellID.RangeMin())), itob(uint64(cellID.RangeMax()))
+			for k, v := index.Seek(min); k != nil && bytes.Compare(k[:8], max) <= 0; k, v = index.Next() {
+				b := records.Get(v)
+				if b == nil {
+					continue
+				}
+				spawnPoint := &SpawnPoint{}
+				if err := json.Unmarshal(b, spawnPoint); err != nil {
+					return err
+				}
+				if box.Contains(spawnPoint) {
+					spawnPoints = append(spawnPoints, spawnPoint)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spawnPoints, nil
+}
+
+type sightingsByFirstSeen []*Sighting
+
+func (a sightingsByFirstSeen) Len() int      { return len(a) }
+func (a sightingsByFirstSeen) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a sightingsByFirstSeen) Less(i, j int) bool {
+	if a[i].FirstSeenMs != a[j].FirstSeenMs {
+		return a[i].FirstSeenMs < a[j].FirstSeenMs
+	}
+	return a[i].EncounterID < a[j].EncounterID
+}
@@ -0,0 +1,112 @@
+// Package store keeps forts, spawn points, Pokémon sightings, gyms and players from feed entries in a bolt database
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	protos "github.com/pogodevorg/POGOProtos-go"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+// ErrNotFound happens when a record does not exist in the store
+var ErrNotFound = errors.New("store: Not found")
+
+// ErrNoAccountID happens when a player profile is saved without the account id of its session
+var ErrNoAccountID = errors.New("store: Player profiles need an account id")
+
+var (
+	fortsBucket             = []byte("forts")
+	gymHistoryBucket        = []byte("gym_history")
+	spawnPointsBucket       = []byte("spawn_points")
+	spawnPointsByCellBucket = []byte("spawn_points_by_cell")
+	sightingsBucket         = []byte("sightings")
+	sightingsByCellBucket   = []byte("sightings_by_cell")
+	playersBucket           = []byte("players")
+)
+
+// Store is a feed saving the map objects and players of every response in a database file
+type Store struct {
+	db *bolt.DB
+
+	mutex sync.Mutex
+	err   error
+}
+
+// Open opens the database at the path, creating it if needed
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{fortsBucket, gymHistoryBucket, spawnPointsBucket, spawnPointsByCellBucket, sightingsBucket, sightingsByCellBucket, playersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Push saves map responses pushed without details of their call, player responses need the account id of an entry
+func (s *Store) Push(entry interface{}) {
+	if message, ok := entry.(proto.Message); ok {
+		s.PushEntry(&api.FeedEntry{Timestamp: time.Now(), Message: message})
+	}
+}
+
+// PushEntry saves map and player responses, ignoring all other entries
+func (s *Store) PushEntry(entry *api.FeedEntry) {
+	var err error
+	switch message := entry.Message.(type) {
+	case *protos.GetMapObjectsResponse:
+		err = s.SaveMapObjects(message, entry.Timestamp)
+	case *protos.GetPlayerResponse:
+		err = s.SavePlayer(entry.AccountID, message, entry.Timestamp)
+	}
+	if err != nil {
+		s.mutex.Lock()
+		s.err = err
+		s.mutex.Unlock()
+	}
+}
+
+// Err returns the last error that happened while saving pushed entries
+func (s *Store) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func timestampMs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func timeFromMs(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	protos "github.com/pogodevorg/POGOProtos-go"
+
+	"github.com/femot/pgoapi-go/api"
+)
+
+var base = time.Date(2016, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func ms(d time.Duration) int64 {
+	return base.Add(d).UnixNano() / int64(time.Millisecond)
+}
+
+func openTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filepath.Join(dir, "scan.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func scan(at time.Duration, forts []*protos.FortData, pokemon ...*protos.WildPokemon) *protos.GetMapObjectsResponse {
+	return &protos.GetMapObjectsResponse{
+		MapCells: []*protos.MapCell{{
+			CurrentTimestampMs: ms(at),
+			Forts:              forts,
+			WildPokemons:       pokemon,
+			SpawnPoints:        []*protos.SpawnPoint{{Latitude: 52.52, Longitude: 13.405}},
+		}},
+	}
+}
+
+func TestForts(t *testing.T) {
+	s, cleanup := openTestStore(t)
+	defer cleanup()
+
+	gym := func(modified time.Duration, team protos.TeamColor, points int64) []*protos.FortData {
+		return []*protos.FortData{{Id: "gym", Type: protos.FortType_GYM, LastModifiedTimestampMs: ms(modified), OwnedByTeam: team, GymPoints: points}}
+	}
+	for _, response := range []*protos.GetMapObjectsResponse{
+		scan(0, gym(0, protos.TeamColor_BLUE, 1000)),
+		scan(time.Minute, gym(0, protos.TeamColor_BLUE, 1000)),
+		scan(2*time.Minute, gym(2*time.Minute, protos.TeamColor_RED, 500)),
+		// An older version of the fort does not replace the newer one
+		scan(3*time.Minute, gym(time.Minute, protos.TeamColor_YELLOW, 100)),
+	} {
+		s.Push(response)
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+
+	fort, err := s.Fort("gym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fort.OwnedByTeam != protos.TeamColor_RED {
+		t.Errorf("expected the latest version of the gym, got %v", fort.OwnedByTeam)
+	}
+	if _, err := s.Fort("unknown"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	history, err := s.GymHistory("gym", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Fort.OwnedByTeam != protos.TeamColor_BLUE || !history[1].Time.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("expected the gym to change hands once, got %v", history)
+	}
+}
+
+func TestGymHistorySameTime(t *testing.T) {
+	s, cleanup := openTestStore(t)
+	defer cleanup()
+
+	// Two changes seen in scans with the same timestamp are both kept
+	s.Push(scan(0, []*protos.FortData{{Id: "gym", Type: protos.FortType_GYM, LastModifiedTimestampMs: ms(0), OwnedByTeam: protos.TeamColor_BLUE}}))
+	s.Push(scan(0, []*protos.FortData{{Id: "gym", Type: protos.FortType_GYM, LastModifiedTimestampMs: ms(time.Second), OwnedByTeam: protos.TeamColor_RED}}))
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+
+	history, err := s.GymHistory("gym", base, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Fort.OwnedByTeam != protos.TeamColor_BLUE || history[1].Fort.OwnedByTeam != protos.TeamColor_RED {
+		t.Errorf("expected both changes in the order they were seen, got %v", history)
+	}
+}
+
+func TestSightings(t *testing.T) {
+	s, cleanup := openTestStore(t)
+	defer cleanup()
+
+	pidgey := &protos.WildPokemon{EncounterId: 1, Latitude: 52.52, Longitude: 13.405, PokemonData: &protos.PokemonData{PokemonId: protos.PokemonId_PIDGEY}}
+	rattata := &protos.WildPokemon{EncounterId: 2, Latitude: 52.60, Longitude: 13.50, PokemonData: &protos.PokemonData{PokemonId: protos.PokemonId_RATTATA}}
+	for _, response := range []*protos.GetMapObjectsResponse{
+		scan(0, nil, pidgey),
+		scan(5*time.Minute, nil, pidgey, rattata),
+		scan(time.Hour, nil, rattata),
+	} {
+		if err := s.SaveMapObjects(response, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	around := (&api.Location{Lat: 52.52, Lon: 13.405}).BoundingBox(1000)
+	sightings, err := s.Sightings(around, base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sightings) != 1 || sightings[0].EncounterID != 1 {
+		t.Fatalf("expected only the Pokémon in the box, got %v", sightings)
+	}
+	if sightings[0].FirstSeenMs != ms(0) || sightings[0].LastSeenMs != ms(5*time.Minute) {
+		t.Errorf("expected the sighting to span both scans, got %v", sightings[0])
+	}
+
+	everywhere := api.BoundingBox{MinLat: 52, MinLon: 13, MaxLat: 53, MaxLon: 14}
+	if sightings, _ := s.Sightings(everywhere, base.Add(30*time.Minute), base.Add(2*time.Hour)); len(sightings) != 1 || sightings[0].EncounterID != 2 {
+		t.Errorf("expected only the Pokémon seen in the time range, got %v", sightings)
+	}
+	if sightings, _ := s.Sightings(everywhere, base, base.Add(2*time.Hour)); len(sightings) != 2 {
+		t.Errorf("expected both Pokémon, got %v", sightings)
+	}
+
+	far := &protos.GetMapObjectsResponse{MapCells: []*protos.MapCell{{
+		CurrentTimestampMs: ms(0),
+		SpawnPoints:        []*protos.SpawnPoint{{Latitude: 52.60, Longitude: 13.50}},
+	}}}
+	if err := s.SaveMapObjects(far, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	spawnPoints, err := s.SpawnPoints(around)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spawnPoints) != 1 || spawnPoints[0].FirstSeenMs != ms(0) || spawnPoints[0].LastSeenMs != ms(time.Hour) {
+		t.Errorf("expected the spawn point seen in every scan, got %v", spawnPoints)
+	}
+	if spawnPoints, _ := s.SpawnPoints(everywhere); len(spawnPoints) != 2 {
+		t.Errorf("expected both spawn points, got %v", spawnPoints)
+	}
+}
+
+func TestPlayerHistory(t *testing.T) {
+	s, cleanup := openTestStore(t)
+	defer cleanup()
+
+	s.PushEntry(&api.FeedEntry{Timestamp: base, AccountID: "ptc:ash", Message: &protos.GetPlayerResponse{PlayerData: &protos.PlayerData{Username: "ash"}}})
+	s.PushEntry(&api.FeedEntry{Timestamp: base.Add(time.Hour), AccountID: "ptc:ash", Message: &protos.GetPlayerResponse{PlayerData: &protos.PlayerData{Username: "ash", Team: protos.TeamColor_RED}}})
+
+	history, err := s.PlayerHistory("ptc:ash", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Player.Team != protos.TeamColor_RED {
+		t.Errorf("expected 2 snapshots, got %v", history)
+	}
+	if _, err := s.PlayerHistory("ptc:misty", base, base.Add(time.Hour)); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// Snapshots taken at the same time are all kept
+	if err := s.SavePlayer("ptc:ash", &protos.GetPlayerResponse{PlayerData: &protos.PlayerData{Username: "ash", Team: protos.TeamColor_BLUE}}, base); err != nil {
+		t.Fatal(err)
+	}
+	history, err = s.PlayerHistory("ptc:ash", base, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Player.Team != protos.TeamColor_BLUE {
+		t.Errorf("expected both snapshots taken at the same time, got %v", history)
+	}
+
+	// Profiles without an account id are not saved under their username
+	if err := s.SavePlayer("", &protos.GetPlayerResponse{PlayerData: &protos.PlayerData{Username: "misty"}}, base); err != ErrNoAccountID {
+		t.Errorf("expected ErrNoAccountID, got %v", err)
+	}
+	if _, err := s.PlayerHistory("misty", base, base); err != ErrNotFound {
+		t.Errorf("expected no history under the username, got %v", err)
+	}
+}